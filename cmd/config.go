@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// applyConfig reads the config file at path with viper and sets every flag
+// of cmd that was not given on the command line, keys are the flag names.
+// Lists are joined for flags that take several values.
+func applyConfig(cmd *cobra.Command, path string) error {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	var err error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed || !v.IsSet(f.Name) {
+			return
+		}
+
+		value := v.GetString(f.Name)
+		if list, ok := v.Get(f.Name).([]interface{}); ok {
+			parts := make([]string, len(list))
+			for i, item := range list {
+				parts[i] = fmt.Sprint(item)
+			}
+			value = strings.Join(parts, ",")
+		}
+
+		if setErr := cmd.Flags().Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("config %s: %w", f.Name, setErr)
+		}
+	})
+	return err
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bata94/reqlab/internal/filter"
+)
+
+// filterFlags adds a jq filter to the output of a non-interactive command
+type filterFlags struct {
+	expr string
+	opts filter.Options
+}
+
+func (f *filterFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.expr, "jq", "", "jq expression the JSON output is filtered with")
+	f.registerOptions(cmd)
+}
+
+func (f *filterFlags) registerOptions(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&f.opts.Raw, "raw-output", "r", false, "Write strings without quotes")
+	cmd.Flags().BoolVarP(&f.opts.Compact, "compact-output", "c", false, "Write each result on a single line")
+}
+
+// apply filters data if an expression was given, otherwise it is returned
+// unchanged
+func (f *filterFlags) apply(data []byte) ([]byte, error) {
+	if f.expr == "" {
+		return data, nil
+	}
+
+	out, err := filter.Apply(context.Background(), f.expr, data, f.opts)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out + "\n"), nil
+}
+
+var filterOpts filterFlags
+
+var filterCmd = &cobra.Command{
+	Use:          "filter <expression> [file]",
+	Short:        "Filter JSON with a jq expression",
+	Long:         "Filter JSON with a jq expression, like jq. The JSON is read from the file or stdin.",
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var (
+			data []byte
+			err  error
+		)
+		if len(args) > 1 && args[1] != "-" {
+			data, err = os.ReadFile(args[1])
+		} else {
+			data, err = io.ReadAll(os.Stdin)
+		}
+		if err != nil {
+			return err
+		}
+
+		filterOpts.expr = args[0]
+		out, err := filterOpts.apply(data)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(string(out))
+		return nil
+	},
+}
+
+func init() {
+	filterOpts.registerOptions(filterCmd)
+	rootCmd.AddCommand(filterCmd)
+}
@@ -1,20 +1,517 @@
 package cmd
 
 import (
-	"github.com/bata94/reqlab/internal/loadtest"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
 	"github.com/spf13/cobra"
+
+	"github.com/bata94/reqlab/internal/loadtest"
+	"github.com/bata94/reqlab/internal/request"
+	"github.com/bata94/reqlab/internal/tui"
+	"github.com/bata94/reqlab/pkgs/apiview"
+)
+
+var ltOpts struct {
+	config      string
+	pacer       string
+	rate        string
+	rateTo      string
+	ramp        time.Duration
+	amplitude   string
+	period      time.Duration
+	steps       []string
+	targets     string
+	format      string
+	order       string
+	spec        string
+	ops         []string
+	server      string
+	mix         []string
+	attack      loadtest.AttackOptions
+	keepAlive   bool
+	results     string
+	encoding    string
+	report      reportFlags
+	tui         bool
+	thresholds  []string
+	abortOnFail bool
+}
+
+// Orders in which targets are hit
+const (
+	ltOrderRoundRobin = "round-robin"
+	ltOrderRandom     = "random"
 )
 
 var ltCmd = &cobra.Command{
-	Use:     "loadtest",
+	Use:     "loadtest [[method] url]",
 	Aliases: []string{"lt"},
 	Short:   "Start a loadtest",
-	Long:    "Start a loadtest",
-	Run: func(cmd *cobra.Command, args []string) {
-		loadtest.Main()
+	Long: `Start a loadtest, requests are sent at a given rate no matter how fast
+the server answers, like Vegeta does. The attack runs until the duration is
+over, the number of requests is sent or it is interrupted with Ctrl+C.
+
+The rate is paced by --pacer:
+  constant  --rate
+  linear    ramps from --rate to --rate-to over --ramp, then stays at --rate-to
+  sine      --rate plus a sine wave of --amplitude and --period
+  steps     every --steps rate:duration after the other, e.g. 50/s:1m,100/s:1m
+
+Instead of a single URL the targets can be read from a file with --targets,
+"-" reads them from stdin. The --format is either Vegeta's HTTP format, a
+method and URL followed by headers and an optional @file for the body:
+
+  POST https://example.com/users
+  Content-Type: application/json
+  @body.json
+  GET https://example.com/users/1
+
+or JSON lines with an optional name and the body base64 encoded:
+
+  {"name": "createUser", "method": "POST", "url": "https://example.com/users", "header": {"Content-Type": ["application/json"]}, "body": "e30="}
+
+With --spec the targets are built from the endpoints of an OpenAPI or Swagger
+spec, all of them or the --operations given by ID. Parameters and bodies are
+filled with their examples or defaults, or values generated from the schema.
+--server replaces the servers of the spec.
+
+--mix weighs targets, e.g. reads=70,writes=30 or getUser=3,listOrders=1.
+Keys are operation IDs, tags, target names or the groups reads and writes,
+the weight of a key is split among its targets. Targets not in the mix are
+not hit.
+
+With --tui the attack is shown live in a dashboard with the current rate,
+latency percentiles, status codes and errors, it can be paused with p and
+aborted with a.
+
+Latencies are service times, from sending a request to the end of its
+response. Response times count from when the request was due by the pacer,
+so they include the time it waited for a free worker and aren't flattered
+when a stalling server holds up the attacker. If requests are sent late a
+warning is shown, raise --max-workers or lower the rate.
+
+--threshold fails the load test with a non-zero exit code if a metric misses
+it, e.g. for CI. Thresholds compare min, mean, p50, p90, p95, p99, p99.9 and
+max response time, error_rate and success in percent, rps, throughput or requests
+with <, <=, >, >= or ==, optionally scoped to the targets of a key like --mix:
+
+  --threshold p95<300ms --threshold error_rate<1% --threshold getUser:rps>=200
+
+With --abort-on-fail the attack stops as soon as a threshold can't be met
+anymore, e.g. max or, if --requests is set, error_rate and percentiles.
+
+Flags can also be set in a config file given with --config, e.g. YAML with
+the flag names as keys.`,
+	Args:         cobra.MaximumNArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		setup, err := newAttackSetup(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		m, err := ltOpts.report.metrics()
+		if err != nil {
+			return err
+		}
+		thresholds, err := ltThresholds(setup.targets)
+		if err != nil {
+			return err
+		}
+
+		var enc loadtest.Encoder
+		if ltOpts.results != "" {
+			f, err := os.Create(ltOpts.results)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			if enc, err = loadtest.NewEncoder(f, ltOpts.encoding); err != nil {
+				return err
+			}
+		}
+
+		var lastCheck time.Time
+		handle := func(res *loadtest.Result) error {
+			m.Add(res)
+			if thresholds != nil {
+				thresholds.Add(res)
+				if ltOpts.abortOnFail && time.Since(lastCheck) >= thresholdCheckInterval {
+					lastCheck = time.Now()
+					if th, ok := thresholds.Breached(ltOpts.attack.Requests); ok {
+						return fmt.Errorf("%w: %s", loadtest.ErrThresholdBreached, th)
+					}
+				}
+			}
+			if enc != nil {
+				return enc.Encode(res)
+			}
+			return nil
+		}
+
+		if ltOpts.tui {
+			err = setup.dashboard(handle)
+		} else {
+			err = setup.run(handle)
+		}
+		aborted := errors.Is(err, loadtest.ErrThresholdBreached)
+		if err != nil && !aborted {
+			return err
+		}
+		m.Close()
+
+		if err := ltOpts.report.write(os.Stdout, m); err != nil {
+			return err
+		}
+		if thresholds == nil {
+			return nil
+		}
+
+		results := thresholds.Check()
+		fmt.Fprintln(os.Stderr)
+		if err := loadtest.WriteThresholds(os.Stderr, results); err != nil {
+			return err
+		}
+		if aborted {
+			return fmt.Errorf("attack aborted, %w", err)
+		}
+		if n := loadtest.Failed(results); n > 0 {
+			return fmt.Errorf("%d of %d thresholds failed", n, len(results))
+		}
+		return nil
 	},
 }
 
+// thresholdCheckInterval is how often thresholds are checked for an early
+// abort
+const thresholdCheckInterval = 100 * time.Millisecond
+
+// ltThresholds creates the checker of the thresholds given, nil if there are
+// none
+func ltThresholds(targets []loadtest.Target) (*loadtest.Thresholds, error) {
+	if len(ltOpts.thresholds) == 0 {
+		return nil, nil
+	}
+
+	list := make([]loadtest.Threshold, 0, len(ltOpts.thresholds))
+	for _, s := range ltOpts.thresholds {
+		th, err := loadtest.ParseThreshold(s)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, th)
+	}
+	return loadtest.NewThresholds(list, targets)
+}
+
+var ltAttackOutput string
+
+var ltAttackCmd = &cobra.Command{
+	Use:   "attack [[method] url]",
+	Short: "Start a loadtest and write its results",
+	Long: `Start a loadtest like loadtest does, but write every result instead of a
+report, to stdout by default. The results can be piped into loadtest report
+or converted with loadtest encode:
+
+  reqlab loadtest attack --rate 100/s --duration 30s https://example.com | reqlab loadtest report
+
+See loadtest --help for the targets and pacers.`,
+	Args:         cobra.MaximumNArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The config may set the output and encoding
+		setup, err := newAttackSetup(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		out := os.Stdout
+		if ltAttackOutput != "" && ltAttackOutput != "-" {
+			f, err := os.Create(ltAttackOutput)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+
+		enc, err := loadtest.NewEncoder(out, ltOpts.encoding)
+		if err != nil {
+			return err
+		}
+		return setup.run(enc.Encode)
+	},
+}
+
+// attackSetup is the attack set up by the flags
+type attackSetup struct {
+	attacker *loadtest.Attacker
+	targets  []loadtest.Target
+	targeter loadtest.Targeter
+}
+
+// newAttackSetup creates the attacker and targets selected by the flags
+func newAttackSetup(cmd *cobra.Command, args []string) (*attackSetup, error) {
+	if ltOpts.config != "" {
+		if err := applyConfig(cmd, ltOpts.config); err != nil {
+			return nil, err
+		}
+	}
+
+	pacer, err := ltPacer()
+	if err != nil {
+		return nil, err
+	}
+	opts := ltOpts.attack
+	opts.Pacer = pacer
+	opts.DisableKeepAlive = !ltOpts.keepAlive
+
+	targets, err := ltTargets(args)
+	if err != nil {
+		return nil, err
+	}
+	targeter, err := ltTargeter(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attackSetup{
+		attacker: loadtest.NewAttacker(opts),
+		targets:  targets,
+		targeter: targeter,
+	}, nil
+}
+
+// title describes the targets
+func (s *attackSetup) title() string {
+	if len(s.targets) == 1 {
+		return s.targets[0].Name
+	}
+	order := ltOpts.order
+	if len(ltOpts.mix) > 0 {
+		order = "weighted"
+	}
+	return fmt.Sprintf("%d targets %s", len(s.targets), order)
+}
+
+// behindWarning is how late a request may be sent before the attack warns
+// that it falls behind its schedule
+const behindWarning = 100 * time.Millisecond
+
+// run runs the attack and passes every result to handle, it stops early on
+// Ctrl+C or once handle fails
+func (s *attackSetup) run(handle func(*loadtest.Result) error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "Attacking %s with a %s pacer ...\n", s.title(), ltOpts.pacer)
+
+	warned := false
+	results := s.attacker.Attack(ctx, s.targeter)
+	for res := range results {
+		if d := res.Delay(); d > behindWarning && !warned {
+			fmt.Fprintf(os.Stderr, "Warning: falling behind schedule by %s with %d workers, response times include the delay. Raise --max-workers or lower the rate.\n",
+				request.FormatDuration(d), s.attacker.Workers())
+			warned = true
+		}
+		if err := handle(res); err != nil {
+			// Let the attack finish in the background, the results are
+			// drained so its workers don't block
+			stop()
+			go func() {
+				for range results {
+				}
+			}()
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Workers: %d\n", s.attacker.Workers())
+	return nil
+}
+
+// dashboard runs the attack like run, but shows it live in the TUI where it
+// can be paused and aborted
+func (s *attackSetup) dashboard(handle func(*loadtest.Result) error) error {
+	return tui.LoadtestView(tui.LoadtestConfig{
+		Attacker: s.attacker,
+		Targeter: s.targeter,
+		Title:    s.title(),
+		OnResult: handle,
+	})
+}
+
+// ltTargets reads the targets file or creates the target of the URL given
+// as arguments
+func ltTargets(args []string) ([]loadtest.Target, error) {
+	if ltOpts.targets != "" && ltOpts.spec != "" {
+		return nil, fmt.Errorf("either give --targets or --spec, not both")
+	}
+	if (ltOpts.targets != "" || ltOpts.spec != "") && len(args) > 0 {
+		return nil, fmt.Errorf("either give a URL or --targets or --spec, not both")
+	}
+
+	if ltOpts.spec != "" {
+		doc, err := apiview.LoadSpec(ltOpts.spec)
+		if err != nil {
+			return nil, err
+		}
+		endpoints, err := apiview.FromOpenAPI(doc)
+		if err != nil {
+			return nil, err
+		}
+		targets, err := loadtest.SpecTargets(endpoints, ltOpts.ops, ltOpts.server)
+		if errors.Is(err, apiview.ErrRelativeServer) {
+			return nil, fmt.Errorf("%w, give the server to attack with --server", err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(targets) == 0 {
+			return nil, loadtest.ErrNoTargets
+		}
+		return targets, nil
+	}
+
+	if ltOpts.targets != "" {
+		targets, err := loadtest.ReadTargetsFile(ltOpts.targets, ltOpts.format)
+		if err != nil {
+			return nil, err
+		}
+		if len(targets) == 0 {
+			return nil, loadtest.ErrNoTargets
+		}
+		return targets, nil
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("give a URL, --targets or --spec to attack")
+	}
+	req := apiview.Request{Method: apiview.GET, URL: args[len(args)-1]}
+	if len(args) == 2 {
+		var err error
+		req.Method, err = apiview.ParseHTTPMethod(args[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return []loadtest.Target{loadtest.NewTarget(req)}, nil
+}
+
+// ltTargeter hits targets in the order selected by the flags, or weighted
+// by the mix
+func ltTargeter(targets []loadtest.Target) (loadtest.Targeter, error) {
+	if len(ltOpts.mix) > 0 {
+		return ltMix(targets)
+	}
+
+	switch ltOpts.order {
+	case ltOrderRoundRobin:
+		return loadtest.NewStaticTargeter(targets...), nil
+	case ltOrderRandom:
+		return loadtest.NewRandomTargeter(targets...), nil
+	}
+	return nil, fmt.Errorf("unknown order %q, use %s or %s", ltOpts.order, ltOrderRoundRobin, ltOrderRandom)
+}
+
+// ltMix hits the targets weighted by the mix
+func ltMix(targets []loadtest.Target) (loadtest.Targeter, error) {
+	mix := make([]loadtest.MixEntry, 0, len(ltOpts.mix))
+	for _, s := range ltOpts.mix {
+		m, err := loadtest.ParseMixEntry(s)
+		if err != nil {
+			return nil, err
+		}
+		mix = append(mix, m)
+	}
+
+	weights, err := loadtest.MixWeights(targets, mix)
+	if err != nil {
+		return nil, err
+	}
+	for i, w := range weights {
+		if w == 0 {
+			fmt.Fprintf(os.Stderr, "%s is not in the mix and not hit\n", targets[i].Name)
+		}
+	}
+
+	return loadtest.NewWeightedTargeter(targets, weights), nil
+}
+
+// ltPacer creates the pacer selected by the flags
+func ltPacer() (loadtest.Pacer, error) {
+	o := loadtest.PacerOptions{
+		Kind:   ltOpts.pacer,
+		Ramp:   ltOpts.ramp,
+		Period: ltOpts.period,
+	}
+
+	var err error
+	if o.Rate, err = loadtest.ParseRate(ltOpts.rate); err != nil {
+		return nil, err
+	}
+	if o.RateTo, err = loadtest.ParseRate(ltOpts.rateTo); err != nil {
+		return nil, err
+	}
+	if o.Amplitude, err = loadtest.ParseRate(ltOpts.amplitude); err != nil {
+		return nil, err
+	}
+	for _, s := range ltOpts.steps {
+		step, err := loadtest.ParseStep(s)
+		if err != nil {
+			return nil, err
+		}
+		o.Steps = append(o.Steps, step)
+	}
+
+	return loadtest.NewPacer(o)
+}
+
+// registerAttackFlags adds the flags setting up an attack to cmd
+func registerAttackFlags(cmd *cobra.Command) {
+	f := cmd.Flags()
+	f.StringVar(&ltOpts.config, "config", "", "Config file with flag values, flags given on the command line win")
+	f.StringVar(&ltOpts.targets, "targets", "", "File to read the targets from, - reads stdin")
+	f.StringVar(&ltOpts.format, "format", loadtest.TargetsHTTP, "Format of the targets: http or json")
+	f.StringVar(&ltOpts.spec, "spec", "", "OpenAPI or Swagger spec to build the targets from")
+	f.StringSliceVar(&ltOpts.ops, "operations", nil, "Operation IDs of the spec to attack, all if empty")
+	f.StringVar(&ltOpts.server, "server", "", "Server URL replacing the servers of the spec")
+	f.StringSliceVar(&ltOpts.mix, "mix", nil, "Weights of the targets as key=weight, e.g. reads=70,writes=30")
+	f.StringVar(&ltOpts.order, "order", ltOrderRoundRobin, "Order in which targets are hit: round-robin or random")
+	f.StringVar(&ltOpts.pacer, "pacer", loadtest.PacerConstant, "Pacer of the rate: constant, linear, sine or steps")
+	f.StringVar(&ltOpts.rate, "rate", "50/s", "Requests per time period, e.g. 100/s or 300/1m, 0 sends as fast as possible")
+	f.StringVar(&ltOpts.rateTo, "rate-to", "0", "Rate the linear pacer ramps to")
+	f.DurationVar(&ltOpts.ramp, "ramp", time.Minute, "Duration of the ramp of the linear pacer")
+	f.StringVar(&ltOpts.amplitude, "amplitude", "0", "Amplitude of the sine pacer, at most the rate")
+	f.DurationVar(&ltOpts.period, "period", time.Minute, "Period of the sine pacer")
+	f.StringSliceVar(&ltOpts.steps, "steps", nil, "Steps of the steps pacer as rate:duration, e.g. 50/s:1m,100/s:1m")
+	f.DurationVar(&ltOpts.attack.Duration, "duration", 0, "Duration of the attack, 0 runs until interrupted")
+	f.Uint64VarP(&ltOpts.attack.Requests, "requests", "n", 0, "Number of requests to send, 0 means no limit")
+	f.DurationVar(&ltOpts.attack.Timeout, "timeout", loadtest.DefaultTimeout, "Timeout of each request")
+	f.BoolVar(&ltOpts.keepAlive, "keepalive", true, "Reuse connections between requests")
+	f.IntVar(&ltOpts.attack.MaxConnections, "max-connections", 0, "Maximum connections per host, 0 means no limit")
+	f.Uint64Var(&ltOpts.attack.Workers, "workers", loadtest.DefaultWorkers, "Workers started up front")
+	f.Uint64Var(&ltOpts.attack.MaxWorkers, "max-workers", 0, "Maximum workers, 0 means no limit")
+	f.StringVar(&ltOpts.encoding, "encoding", loadtest.EncodingBinary, "Encoding of the results: binary, json or csv")
+}
+
 func init() {
+	registerAttackFlags(ltCmd)
+	ltCmd.Flags().StringVar(&ltOpts.results, "results", "", "File to write the results to, for loadtest report")
+	ltCmd.Flags().StringSliceVar(&ltOpts.thresholds, "threshold", nil, "Threshold the attack has to meet like p95<300ms or getUser:error_rate<1%, repeatable")
+	ltCmd.Flags().BoolVar(&ltOpts.abortOnFail, "abort-on-fail", false, "Abort the attack once a threshold can't be met anymore")
+	ltCmd.Flags().BoolVar(&ltOpts.tui, "tui", false, "Show the attack live in a dashboard, the report follows once it is closed")
+	ltOpts.report.register(ltCmd)
+
+	registerAttackFlags(ltAttackCmd)
+	ltAttackCmd.Flags().StringVarP(&ltAttackOutput, "output", "o", "", "File to write the results to, stdout by default")
+	ltCmd.AddCommand(ltAttackCmd)
+
 	rootCmd.AddCommand(ltCmd)
 }
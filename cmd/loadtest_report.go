@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bata94/reqlab/internal/loadtest"
+)
+
+// reportFlags select the report of a load test
+type reportFlags struct {
+	typ     string
+	buckets string
+}
+
+func (r *reportFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&r.typ, "report", loadtest.ReportText, "Report: text, json or hist")
+	cmd.Flags().StringVar(&r.buckets, "buckets", "[0,10ms,50ms,100ms,250ms,500ms,1s]", "Bounds of the buckets of the hist report")
+}
+
+// metrics creates the metrics to collect the results in
+func (r *reportFlags) metrics() (*loadtest.Metrics, error) {
+	m := &loadtest.Metrics{}
+	if r.typ == loadtest.ReportHistogram {
+		buckets, err := loadtest.ParseBuckets(r.buckets)
+		if err != nil {
+			return nil, err
+		}
+		m.Buckets = buckets
+	}
+	return m, nil
+}
+
+func (r *reportFlags) write(w io.Writer, m *loadtest.Metrics) error {
+	return loadtest.WriteReport(w, m, r.typ)
+}
+
+var ltReportOpts reportFlags
+
+var ltReportCmd = &cobra.Command{
+	Use:   "report [results...]",
+	Short: "Report on the results of load tests",
+	Long: `Report on the results of load tests, written by loadtest attack or loadtest
+--results in any encoding. The results are read from the files or stdin,
+several files are reported on as one, e.g. of attacks from several machines.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := ltReportOpts.metrics()
+		if err != nil {
+			return err
+		}
+
+		dec, closeAll, err := openResults(args)
+		if err != nil {
+			return err
+		}
+		defer closeAll()
+
+		for {
+			var res loadtest.Result
+			if err := dec.Decode(&res); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return err
+			}
+			m.Add(&res)
+		}
+		m.Close()
+
+		return ltReportOpts.write(os.Stdout, m)
+	},
+}
+
+// openResults decodes the results of all files merged by timestamp, no
+// files or "-" read stdin
+func openResults(paths []string) (loadtest.Decoder, func(), error) {
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	var (
+		decs  []loadtest.Decoder
+		files []*os.File
+	)
+	closeAll := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+
+	for _, path := range paths {
+		if path == "-" {
+			decs = append(decs, loadtest.NewDecoder(os.Stdin))
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		files = append(files, f)
+		decs = append(decs, loadtest.NewDecoder(f))
+	}
+
+	return loadtest.Merge(decs...), closeAll, nil
+}
+
+var ltEncodeOpts struct {
+	to     string
+	output string
+}
+
+var ltEncodeCmd = &cobra.Command{
+	Use:   "encode [results...]",
+	Short: "Convert results of load tests to another encoding",
+	Long: `Convert results of load tests to another encoding, e.g. to CSV to analyze
+them elsewhere. The results are read from the files or stdin in any encoding,
+several files are merged into one stream ordered by timestamp.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := os.Stdout
+		if ltEncodeOpts.output != "" && ltEncodeOpts.output != "-" {
+			f, err := os.Create(ltEncodeOpts.output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+
+		enc, err := loadtest.NewEncoder(out, ltEncodeOpts.to)
+		if err != nil {
+			return err
+		}
+		dec, closeAll, err := openResults(args)
+		if err != nil {
+			return err
+		}
+		defer closeAll()
+
+		for {
+			var res loadtest.Result
+			if err := dec.Decode(&res); errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if err := enc.Encode(&res); err != nil {
+				return err
+			}
+		}
+	},
+}
+
+func init() {
+	ltReportOpts.register(ltReportCmd)
+	ltCmd.AddCommand(ltReportCmd)
+
+	ltEncodeCmd.Flags().StringVar(&ltEncodeOpts.to, "to", loadtest.EncodingJSON, "Encoding to convert to: binary, json or csv")
+	ltEncodeCmd.Flags().StringVarP(&ltEncodeOpts.output, "output", "o", "", "File to write the results to, stdout by default")
+	ltCmd.AddCommand(ltEncodeCmd)
+}
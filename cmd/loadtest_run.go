@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bata94/reqlab/internal/loadtest"
+)
+
+var ltRunOpts struct {
+	timeout        time.Duration
+	keepAlive      bool
+	maxConnections int
+	results        string
+	encoding       string
+	report         reportFlags
+}
+
+var ltRunCmd = &cobra.Command{
+	Use:   "run <scenario.yaml>",
+	Short: "Run a scenario of virtual users",
+	Long: `Run a scenario of virtual users (VUs), a closed model load test like k6
+runs: every VU sends the steps of the scenario one after the other in a loop
+and waits for each response, with a think time between the steps. The number
+of VUs ramps linearly over the stages, VUs stopped by a ramp down finish
+their iteration first.
+
+  name: checkout
+  spec: openapi.yaml          # Steps may call its operations
+  server: http://localhost:8080
+  think: 1s                   # After every step
+  headers:
+    Accept: application/json
+  stages:                     # Or vus and duration for a constant number
+    - {duration: 30s, vus: 10}
+    - {duration: 1m, vus: 10}
+    - {duration: 30s, vus: 0}
+  steps:
+    - name: login
+      method: POST
+      url: /login
+      body: '{"user": "user{{vu}}", "password": "secret"}'
+      extract:
+        token: .token         # jq expression on the JSON response
+    - operation: listOrders
+      headers:
+        Authorization: Bearer {{token}}
+      extract:
+        order: .[0].id
+    - operation: getOrder
+      params:
+        id: "{{order}}"
+      headers:
+        Authorization: Bearer {{token}}
+      think: 3s
+
+Variables like {{token}} are set by extract, vars of the scenario or are the
+built-in vu and iteration. A failed step starts the iteration over. With
+iterations every VU stops after as many, without stages all VUs run them.
+
+The report has the metrics of every step after the total. Results are
+written with --results like loadtest does, their target is the step.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sc, err := loadtest.LoadScenario(args[0])
+		if err != nil {
+			return err
+		}
+
+		total, err := ltRunOpts.report.metrics()
+		if err != nil {
+			return err
+		}
+		m := loadtest.NewScenarioMetrics(sc, total)
+
+		var enc loadtest.Encoder
+		if ltRunOpts.results != "" {
+			f, err := os.Create(ltRunOpts.results)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			if enc, err = loadtest.NewEncoder(f, ltRunOpts.encoding); err != nil {
+				return err
+			}
+		}
+
+		runner := loadtest.NewScenarioRunner(sc, loadtest.ScenarioOptions{
+			Timeout:          ltRunOpts.timeout,
+			DisableKeepAlive: !ltRunOpts.keepAlive,
+			MaxConnections:   ltRunOpts.maxConnections,
+		})
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		name := sc.Name
+		if name == "" {
+			name = args[0]
+		}
+		fmt.Fprintf(os.Stderr, "Running %s with up to %d VUs ...\n", name, sc.MaxVUs())
+
+		results := runner.Run(ctx)
+		for res := range results {
+			m.Add(res)
+			if enc == nil {
+				continue
+			}
+			if err := enc.Encode(res); err != nil {
+				stop()
+				go func() {
+					for range results {
+					}
+				}()
+				return err
+			}
+		}
+		m.Close()
+
+		return loadtest.WriteScenarioReport(os.Stdout, m, ltRunOpts.report.typ)
+	},
+}
+
+func init() {
+	f := ltRunCmd.Flags()
+	f.DurationVar(&ltRunOpts.timeout, "timeout", loadtest.DefaultTimeout, "Timeout of each request")
+	f.BoolVar(&ltRunOpts.keepAlive, "keepalive", true, "Reuse connections between requests")
+	f.IntVar(&ltRunOpts.maxConnections, "max-connections", 0, "Maximum connections per host, 0 means no limit")
+	f.StringVar(&ltRunOpts.results, "results", "", "File to write the results to, for loadtest report")
+	f.StringVar(&ltRunOpts.encoding, "encoding", loadtest.EncodingBinary, "Encoding of the results: binary, json or csv")
+	ltRunOpts.report.register(ltRunCmd)
+
+	ltCmd.AddCommand(ltRunCmd)
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -20,11 +21,28 @@ var rootCmd = &cobra.Command{
 	Long:  "CLI-Tool to test APIs",
 }
 
-func Execute() {
+// ExitError ends reqlab with Code, the command already wrote its output
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Execute runs the command given on the command line and returns the exit
+// code of reqlab
+func Execute() int {
 	err := rootCmd.Execute()
+	var exit *ExitError
+	if errors.As(err, &exit) {
+		return exit.Code
+	}
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return 1
 	}
+	return 0
 }
 
 func init() {
@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bata94/reqlab/internal/request"
+	"github.com/bata94/reqlab/pkgs/apiview"
+)
+
+// Output modes of the send command
+const (
+	sendOutputBody = "body"
+	sendOutputFull = "full"
+	sendOutputJSON = "json"
+)
+
+var sendOpts struct {
+	spec    string
+	server  string
+	headers []string
+	params  []string
+	data    string
+	output  string
+	timing  bool
+	timeout time.Duration
+	filter  filterFlags
+}
+
+var sendCmd = &cobra.Command{
+	Use:   "send [method] <url | operation-id>",
+	Short: "Send a single request",
+	Long: `Send a single request and print its response, e.g. in scripts or CI jobs.
+
+The method defaults to GET, or POST if a body is given. With --spec the
+request can be given by the operation ID of an endpoint instead of a URL, it
+is prefilled with the examples of the spec like in the TUI. --server replaces
+the servers of the spec, e.g. if they are relative.
+
+The body is given with -d or --data, @file reads it from a file and @- from
+stdin. On send -d is --data, --debug has no shorthand here.
+
+The output is selected with --output:
+  body  only the response body (default)
+  full  status line, headers and body
+  json  a JSON object with status, headers, body and timing
+
+The exit code is 0 for 1xx and 2xx responses, 3, 4 or 5 for 3xx, 4xx or 5xx
+responses and 1 if the request failed.`,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		req, err := sendRequest(args)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), sendOpts.timeout)
+		defer cancel()
+
+		resp, err := request.Execute(ctx, nil, req)
+		if err != nil {
+			return err
+		}
+
+		out, err := sendOutput(req, resp)
+		if err != nil {
+			return err
+		}
+		os.Stdout.Write(out)
+
+		if sendOpts.timing {
+			fmt.Fprintln(os.Stderr, resp.Timing.Waterfall(40))
+		}
+
+		if code := resp.StatusCode / 100; code >= 3 {
+			// The response is the output, not an error to print
+			cmd.SilenceErrors = true
+			return &ExitError{Code: code}
+		}
+		return nil
+	},
+}
+
+// sendRequest builds the request from the arguments and flags
+func sendRequest(args []string) (apiview.Request, error) {
+	target := args[len(args)-1]
+
+	var req apiview.Request
+	if sendOpts.spec != "" && !strings.Contains(target, "://") {
+		doc, err := apiview.LoadSpec(sendOpts.spec)
+		if err != nil {
+			return req, err
+		}
+		endpoints, err := apiview.FromOpenAPI(doc)
+		if err != nil {
+			return req, err
+		}
+
+		e, ok := apiview.FindEndpoint(endpoints, target)
+		if !ok {
+			return req, fmt.Errorf("no operation %q in %s", target, sendOpts.spec)
+		}
+		if sendOpts.server != "" {
+			e.Servers = []string{sendOpts.server}
+		}
+		if err := e.CheckServer(); err != nil {
+			return req, fmt.Errorf("%w, give the server with --server", err)
+		}
+		req = apiview.NewRequest(e)
+	} else {
+		req.Method = apiview.GET
+		req.URL = target
+	}
+
+	for _, p := range sendOpts.params {
+		key, value, ok := strings.Cut(p, "=")
+		if !ok {
+			return req, fmt.Errorf("invalid param %q, expected key=value", p)
+		}
+		req.SetParam(key, value)
+	}
+
+	// A header given once replaces the one of the spec, given again it is
+	// sent once per value
+	given := map[string]bool{}
+	for _, h := range sendOpts.headers {
+		key, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return req, fmt.Errorf("invalid header %q, expected \"Key: Value\"", h)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		canonical := http.CanonicalHeaderKey(key)
+		if !given[canonical] {
+			given[canonical] = true
+			req.SetHeader(key, value)
+			continue
+		}
+		req.Headers = append(req.Headers, apiview.Header{Key: key, Value: value, Enabled: true})
+	}
+
+	if sendOpts.data != "" {
+		body, err := readData(sendOpts.data)
+		if err != nil {
+			return req, err
+		}
+		req.Body = body
+
+		if req.ContentType == "" {
+			req.ContentType = "application/x-www-form-urlencoded"
+			if json.Valid([]byte(body)) {
+				req.ContentType = "application/json"
+			}
+		}
+		if len(args) == 1 && sendOpts.spec == "" {
+			req.Method = apiview.POST
+		}
+	}
+
+	if len(args) == 2 {
+		method, err := apiview.ParseHTTPMethod(args[0])
+		if err != nil {
+			return req, err
+		}
+		req.Method = method
+	}
+
+	return req, nil
+}
+
+// readData reads the body given with --data, @file reads a file and @- stdin
+func readData(data string) (string, error) {
+	if !strings.HasPrefix(data, "@") {
+		return data, nil
+	}
+
+	var (
+		body []byte
+		err  error
+	)
+	if data == "@-" {
+		body, err = io.ReadAll(os.Stdin)
+	} else {
+		body, err = os.ReadFile(data[1:])
+	}
+	return string(body), err
+}
+
+// sendEnvelope is the response written by --output json, durations are in
+// milliseconds
+type sendEnvelope struct {
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	Status     int                 `json:"status"`
+	StatusText string              `json:"statusText"`
+	Proto      string              `json:"proto"`
+	Headers    map[string][]string `json:"headers"`
+	Body       json.RawMessage     `json:"body"`
+	Size       int                 `json:"size"`
+	Timing     sendTiming          `json:"timing"`
+}
+
+type sendTiming struct {
+	DNSLookup        float64 `json:"dnsLookup"`
+	TCPConnect       float64 `json:"tcpConnect"`
+	TLSHandshake     float64 `json:"tlsHandshake"`
+	ServerProcessing float64 `json:"serverProcessing"`
+	ContentTransfer  float64 `json:"contentTransfer"`
+	FirstByte        float64 `json:"firstByte"`
+	Total            float64 `json:"total"`
+	ConnReused       bool    `json:"connReused"`
+}
+
+// sendOutput renders resp as selected by --output, --jq filters the body or
+// for json output the whole envelope
+func sendOutput(req apiview.Request, resp *request.Response) ([]byte, error) {
+	switch sendOpts.output {
+	case sendOutputBody:
+		return sendOpts.filter.apply(resp.Body)
+	case sendOutputFull:
+		body, err := sendOpts.filter.apply(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		head := bytes.ReplaceAll(resp.Head(), []byte("\r\n"), []byte("\n"))
+		return append(head, body...), nil
+	case sendOutputJSON:
+		data, err := json.MarshalIndent(newSendEnvelope(req, resp), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if sendOpts.filter.expr == "" {
+			return append(data, '\n'), nil
+		}
+		return sendOpts.filter.apply(data)
+	}
+	return nil, fmt.Errorf("unknown output %q, use %s, %s or %s", sendOpts.output, sendOutputBody, sendOutputFull, sendOutputJSON)
+}
+
+func newSendEnvelope(req apiview.Request, resp *request.Response) sendEnvelope {
+	// JSON bodies are embedded as is, anything else as a string
+	body := json.RawMessage(bytes.TrimSpace(resp.Body))
+	if !json.Valid(body) {
+		body, _ = json.Marshal(string(resp.Body))
+	}
+
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	t := resp.Timing
+
+	return sendEnvelope{
+		Method:     resp.Request.Method,
+		URL:        resp.Request.URL.String(),
+		Status:     resp.StatusCode,
+		StatusText: http.StatusText(resp.StatusCode),
+		Proto:      resp.Proto,
+		Headers:    resp.Header,
+		Body:       body,
+		Size:       len(resp.Body),
+		Timing: sendTiming{
+			DNSLookup:        ms(t.DNSLookup),
+			TCPConnect:       ms(t.TCPConnect),
+			TLSHandshake:     ms(t.TLSHandshake),
+			ServerProcessing: ms(t.ServerProcessing),
+			ContentTransfer:  ms(t.ContentTransfer),
+			FirstByte:        ms(t.FirstByte),
+			Total:            ms(t.Total),
+			ConnReused:       t.ConnReused,
+		},
+	}
+}
+
+func init() {
+	f := sendCmd.Flags()
+	f.StringVarP(&sendOpts.spec, "spec", "s", "", "OpenAPI or Swagger spec to look up operation IDs in")
+	f.StringVar(&sendOpts.server, "server", "", "Server URL replacing the servers of the spec")
+	f.StringArrayVarP(&sendOpts.headers, "header", "H", nil, "Header to send as \"Key: Value\", can be repeated")
+	f.StringArrayVarP(&sendOpts.params, "param", "p", nil, "Path or query param as key=value, can be repeated")
+	f.StringVarP(&sendOpts.data, "data", "d", "", "Body to send, @file reads it from a file and @- from stdin")
+	// Shadows the persistent --debug to free its shorthand for --data
+	f.BoolVar(&Debug, "debug", false, "Display debugging output in the console. (default: false)")
+	f.StringVarP(&sendOpts.output, "output", "o", sendOutputBody, "Output: body, full or json")
+	f.BoolVarP(&sendOpts.timing, "timing", "t", false, "Write the timing waterfall to stderr")
+	f.DurationVar(&sendOpts.timeout, "timeout", 30*time.Second, "Timeout of the whole request")
+	sendOpts.filter.register(sendCmd)
+	rootCmd.AddCommand(sendCmd)
+}
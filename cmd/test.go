@@ -6,34 +6,31 @@ import (
 	"os"
 	"time"
 
-	"github.com/bata94/reqlab/pkgs/apiview/openapi"
+	"github.com/bata94/reqlab/pkgs/apiview"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+var testFilter filterFlags
+
 var testCmd = &cobra.Command{
-	Use:   "test",
+	Use:   "test [spec]",
 	Short: "Test cmd",
 	Long:  "Text cmd",
+	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		log.Info("Test cmd")
 
-		jsonFile, err := os.Open("tmp/swagger.json")
-
-		if err != nil {
-			log.Error(err)
+		specPath := "tmp/swagger.json"
+		if len(args) > 0 {
+			specPath = args[0]
 		}
 
-		defer jsonFile.Close()
-		log.Info("Successfully Opened JsonFile")
-
-		openapiCollection := openapi.OpenAPI{}
-		jsonDecoder := json.NewDecoder(jsonFile)
-
-		err = jsonDecoder.Decode(&openapiCollection)
+		openapiCollection, err := apiview.LoadSpec(specPath)
 		if err != nil {
 			log.Fatal(err)
 		}
+		log.Info("Successfully loaded ", specPath)
 
 		// log.Info(json.Marshal(openapiCollection))
 
@@ -52,6 +49,12 @@ var testCmd = &cobra.Command{
 			return
 		}
 
+		data, err = testFilter.apply(data)
+		if err != nil {
+			log.Error("Error filtering JSON:", err)
+			return
+		}
+
 		// Write the JSON data to the file
 		_, err = file.Write(data)
 		if err != nil {
@@ -64,5 +67,6 @@ var testCmd = &cobra.Command{
 }
 
 func init() {
+	testFilter.register(testCmd)
 	rootCmd.AddCommand(testCmd)
 }
@@ -2,15 +2,30 @@ package cmd
 
 import (
 	"github.com/bata94/reqlab/internal/tui"
+	"github.com/bata94/reqlab/pkgs/apiview"
+	"github.com/bata94/reqlab/pkgs/apiview/openapi"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 var tuiCmd = &cobra.Command{
-	Use:   "tui",
-	Short: "Launch the TUI",
-	Long:  "Launch the TUI, this is the main use of the App",
-	Run: func(cmd *cobra.Command, args []string) {
-		tui.MainView()
+	Use:          "tui [spec]",
+	Short:        "Launch the TUI",
+	Long:         "Launch the TUI, this is the main use of the App. The endpoints of the given OpenAPI or Swagger spec are listed to be sent.",
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var doc *openapi.OpenAPI
+		if len(args) > 0 {
+			var err error
+			doc, err = apiview.LoadSpec(args[0])
+			if err != nil {
+				return err
+			}
+			log.Info("Successfully loaded ", args[0])
+		}
+
+		return tui.MainView(doc)
 	},
 }
 
@@ -0,0 +1,117 @@
+package filter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/itchyny/gojq"
+)
+
+// Options control how the results of a filter are written
+type Options struct {
+	Raw     bool // Write strings without quotes, like jq -r
+	Compact bool // Write each result on a single line, like jq -c
+}
+
+// Filter is a compiled jq expression
+type Filter struct {
+	code *gojq.Code
+}
+
+// Compile parses and compiles a jq expression
+func Compile(expr string) (*Filter, error) {
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+
+	return &Filter{code: code}, nil
+}
+
+// Run applies the filter to every JSON value in input, like jq several
+// values in a row are filtered one after the other
+func (f *Filter) Run(ctx context.Context, input []byte) ([]interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(input))
+	d.UseNumber()
+
+	var results []interface{}
+	for {
+		var v interface{}
+		if err := d.Decode(&v); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("input is no valid JSON: %w", err)
+		}
+
+		iter := f.code.RunWithContext(ctx, v)
+		for {
+			result, ok := iter.Next()
+			if !ok {
+				break
+			}
+			if err, ok := result.(error); ok {
+				var haltErr *gojq.HaltError
+				if errors.As(err, &haltErr) && haltErr.Value() == nil {
+					break
+				}
+				return nil, err
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+// Format writes results the way jq prints them, one after the other
+func Format(results []interface{}, opts Options) (string, error) {
+	var b strings.Builder
+	for _, result := range results {
+		if s, ok := result.(string); ok && opts.Raw {
+			b.WriteString(s)
+			b.WriteString("\n")
+			continue
+		}
+
+		data, err := gojq.Marshal(result)
+		if err != nil {
+			return "", err
+		}
+		if !opts.Compact {
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, data, "", "  "); err != nil {
+				return "", err
+			}
+			data = indented.Bytes()
+		}
+		b.Write(data)
+		b.WriteString("\n")
+	}
+
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// Apply compiles expr, runs it against input and formats the results
+func Apply(ctx context.Context, expr string, input []byte, opts Options) (string, error) {
+	f, err := Compile(expr)
+	if err != nil {
+		return "", err
+	}
+
+	results, err := f.Run(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	return Format(results, opts)
+}
@@ -0,0 +1,235 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// Kind is the syntax of a body as far as formatting is concerned
+type Kind int32
+
+const (
+	KindText Kind = iota
+	KindJSON
+	KindXML
+	KindHTML
+	KindForm
+)
+
+// KindOf classifies a media type, e.g. "application/problem+json" is JSON
+func KindOf(contentType string) Kind {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	switch {
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		return KindJSON
+	case mediaType == "text/html", mediaType == "application/xhtml+xml":
+		return KindHTML
+	case mediaType == "application/xml", mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+		return KindXML
+	case mediaType == "application/x-www-form-urlencoded":
+		return KindForm
+	}
+	return KindText
+}
+
+// Pretty formats body according to its kind: JSON and XML are indented,
+// HTML is tidied up and form data is decoded into a key = value table.
+// Text is returned as is.
+func Pretty(kind Kind, body []byte) (string, error) {
+	switch kind {
+	case KindJSON:
+		var b bytes.Buffer
+		if err := json.Indent(&b, bytes.TrimSpace(body), "", "  "); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	case KindXML:
+		return indentMarkup(body, false)
+	case KindHTML:
+		return indentMarkup(body, true)
+	case KindForm:
+		return formTable(string(body))
+	}
+	return string(body), nil
+}
+
+// indentMarkup puts every element on its own line, elements that only
+// contain text stay on a single line
+func indentMarkup(body []byte, html bool) (string, error) {
+	d := xml.NewDecoder(bytes.NewReader(body))
+	if html {
+		d.Strict = false
+		d.AutoClose = xml.HTMLAutoClose
+		d.Entity = xml.HTMLEntity
+	}
+
+	var (
+		b      strings.Builder
+		depth  int
+		last   xml.Token // Previous token written, to keep <a>text</a> inline
+		open   bool      // The last start tag is not closed with > yet
+		inline bool      // The last text directly follows its start tag
+	)
+
+	newline := func() {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(strings.Repeat("  ", depth))
+	}
+	closeOpen := func() {
+		if open {
+			b.WriteString(">")
+			open = false
+		}
+	}
+
+	for {
+		var (
+			tok xml.Token
+			err error
+		)
+		if html {
+			tok, err = d.Token()
+		} else {
+			tok, err = d.RawToken()
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			closeOpen()
+			newline()
+			b.WriteString("<" + markupName(t.Name))
+			for _, attr := range t.Attr {
+				fmt.Fprintf(&b, ` %s="%s"`, markupName(attr.Name), attrEscaper.Replace(attr.Value))
+			}
+			open = true
+			depth++
+			last = t
+		case xml.EndElement:
+			depth--
+			if open {
+				// Nothing between start and end
+				open = false
+				if html && isVoidElement(t.Name.Local) {
+					b.WriteString(">")
+				} else if html {
+					b.WriteString("></" + markupName(t.Name) + ">")
+				} else {
+					b.WriteString("/>")
+				}
+				last = t
+				continue
+			}
+			if _, ok := last.(xml.CharData); !ok || !inline {
+				newline()
+			}
+			b.WriteString("</" + markupName(t.Name) + ">")
+			last = t
+		case xml.CharData:
+			text := strings.TrimSpace(string(t))
+			if text == "" {
+				continue
+			}
+			_, afterStart := last.(xml.StartElement)
+			closeOpen()
+			if !afterStart {
+				newline()
+			}
+			inline = afterStart
+			b.WriteString(textEscaper.Replace(text))
+			last = t.Copy()
+		case xml.Comment:
+			closeOpen()
+			newline()
+			b.WriteString("<!--" + string(t) + "-->")
+			last = t.Copy()
+		case xml.ProcInst:
+			closeOpen()
+			newline()
+			b.WriteString("<?" + t.Target + " " + string(t.Inst) + "?>")
+			last = t.Copy()
+		case xml.Directive:
+			closeOpen()
+			newline()
+			b.WriteString("<!" + string(t) + ">")
+			last = t.Copy()
+		}
+	}
+	closeOpen()
+
+	return b.String(), nil
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;")
+)
+
+func markupName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+func isVoidElement(name string) bool {
+	for _, v := range xml.HTMLAutoClose {
+		if strings.EqualFold(v, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// formTable decodes form data into one aligned key = value line per pair,
+// in the order they were sent
+func formTable(body string) (string, error) {
+	type pair struct{ key, value string }
+
+	var (
+		pairs []pair
+		width int
+	)
+	for _, part := range strings.Split(strings.TrimSpace(body), "&") {
+		if part == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(part, "=")
+
+		key, err := url.QueryUnescape(k)
+		if err != nil {
+			return "", err
+		}
+		value, err := url.QueryUnescape(v)
+		if err != nil {
+			return "", err
+		}
+
+		pairs = append(pairs, pair{key, value})
+		width = max(width, len(key))
+	}
+
+	lines := make([]string, len(pairs))
+	for i, p := range pairs {
+		lines[i] = fmt.Sprintf("%-*s = %s", width, p.key, p.value)
+	}
+	return strings.Join(lines, "\n"), nil
+}
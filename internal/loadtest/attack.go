@@ -0,0 +1,315 @@
+package loadtest
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bata94/reqlab/pkgs/apiview"
+)
+
+// Defaults of AttackOptions
+const (
+	DefaultWorkers = 10
+	DefaultTimeout = 30 * time.Second
+)
+
+// AttackOptions configure an attack, zero values use the defaults
+type AttackOptions struct {
+	Pacer            Pacer         // Nil sends as fast as the workers can
+	Duration         time.Duration // Zero attacks until Requests are sent or the attack is stopped
+	Requests         uint64        // Zero sends requests until the duration is over
+	Timeout          time.Duration // Per request
+	DisableKeepAlive bool
+	MaxConnections   int    // Per host, zero means no limit
+	Workers          uint64 // Workers started up front
+	MaxWorkers       uint64 // Workers are added up to this if the rate is not met, zero means no limit
+}
+
+// Attacker sends requests at a given rate with a pool of workers, like
+// Vegeta does. The pool grows when all workers are busy.
+type Attacker struct {
+	opts    AttackOptions
+	client  *http.Client
+	workers atomic.Uint64
+
+	mu       sync.Mutex
+	began    time.Time     // Start of the attack, moved forward by pauses
+	pausedAt time.Time     // Start of the current pause
+	resumed  chan struct{} // Closed on resume, nil if not paused
+}
+
+// NewAttacker creates an attacker, its HTTP client is set up from opts
+func NewAttacker(opts AttackOptions) *Attacker {
+	if opts.Workers == 0 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.MaxWorkers != 0 && opts.MaxWorkers < opts.Workers {
+		opts.MaxWorkers = opts.Workers
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if r, ok := opts.Pacer.(Rate); ok && r.IsZero() {
+		opts.Pacer = nil
+	}
+
+	return &Attacker{
+		opts:   opts,
+		client: newClient(opts.Timeout, opts.DisableKeepAlive, opts.MaxConnections, int(opts.Workers)),
+	}
+}
+
+// newClient creates the HTTP client of a load test, idle connections are
+// kept for as many requests as run at once
+func newClient(timeout time.Duration, disableKeepAlive bool, maxConns, idleConns int) *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		DisableKeepAlives:   disableKeepAlive,
+		MaxConnsPerHost:     maxConns,
+		MaxIdleConnsPerHost: idleConns,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		ForceAttemptHTTP2:   true,
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// Workers returns the number of workers currently started
+func (a *Attacker) Workers() uint64 {
+	return a.workers.Load()
+}
+
+// Options returns the options of the attack with the defaults filled in
+func (a *Attacker) Options() AttackOptions {
+	return a.opts
+}
+
+// Pause stops sending requests until Resume is called, requests in flight
+// still finish. Paused time doesn't count towards the duration.
+func (a *Attacker) Pause() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.resumed == nil {
+		a.resumed = make(chan struct{})
+		a.pausedAt = time.Now()
+	}
+}
+
+// Resume continues a paused attack where it stopped
+func (a *Attacker) Resume() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.resumed != nil {
+		close(a.resumed)
+		a.resumed = nil
+		a.began = a.began.Add(time.Since(a.pausedAt))
+	}
+}
+
+// Paused reports whether the attack is paused
+func (a *Attacker) Paused() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.resumed != nil
+}
+
+// Elapsed returns the time the attack has been running, without pauses
+func (a *Attacker) Elapsed() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.began.IsZero() {
+		return 0
+	}
+	end := time.Now()
+	if a.resumed != nil {
+		end = a.pausedAt
+	}
+	return end.Sub(a.began)
+}
+
+// waitResumed blocks while the attack is paused, it returns false if ctx
+// is done first
+func (a *Attacker) waitResumed(ctx context.Context) bool {
+	a.mu.Lock()
+	resumed := a.resumed
+	a.mu.Unlock()
+
+	if resumed == nil {
+		return true
+	}
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Attack hits the targets of tr until the duration is over, the number of
+// requests is sent or ctx is done. A result is sent for every request, the
+// channel is closed once the requests in flight have finished.
+func (a *Attacker) Attack(ctx context.Context, tr Targeter) <-chan *Result {
+	var (
+		results = make(chan *Result)
+		ticks   = make(chan tick)
+		wg      sync.WaitGroup
+	)
+
+	spawn := func() {
+		a.workers.Add(1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range ticks {
+				results <- a.hit(ctx, tr, t)
+			}
+		}()
+	}
+	for range a.opts.Workers {
+		spawn()
+	}
+
+	go func() {
+		defer close(results)
+		defer wg.Wait()
+		defer close(ticks)
+
+		a.mu.Lock()
+		a.began = time.Now()
+		a.mu.Unlock()
+
+		// due is when the last hit was due by the schedule of the pacer
+		var due time.Duration
+		for hits := uint64(0); a.opts.Requests == 0 || hits < a.opts.Requests; {
+			if !a.waitResumed(ctx) {
+				return
+			}
+
+			elapsed := a.Elapsed()
+			if a.opts.Duration > 0 && elapsed >= a.opts.Duration {
+				return
+			}
+
+			t := tick{seq: hits, intended: time.Now()}
+			if a.opts.Pacer != nil {
+				wait, stop := a.opts.Pacer.Pace(elapsed, hits)
+				if stop {
+					return
+				}
+				if wait > 0 {
+					if !sleep(ctx, wait) {
+						return
+					}
+					continue
+				}
+
+				// The hit may be overdue, its intended time is found from
+				// the one of the last hit
+				next, _ := a.opts.Pacer.Pace(due, hits)
+				due += next
+				t.intended = t.intended.Add(due - elapsed)
+			}
+
+			select {
+			case ticks <- t:
+				hits++
+				continue
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			// All workers are busy, the schedule can only be kept with
+			// another one. Without a pacer there is no schedule to keep.
+			if a.opts.Pacer != nil && (a.opts.MaxWorkers == 0 || a.Workers() < a.opts.MaxWorkers) {
+				spawn()
+			}
+
+			select {
+			case ticks <- t:
+				hits++
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return results
+}
+
+// tick is a hit handed to a worker
+type tick struct {
+	seq      uint64
+	intended time.Time // When the hit was due, it is sent later if all workers are busy
+}
+
+// hit sends the request of the next target
+func (a *Attacker) hit(ctx context.Context, tr Targeter, t tick) *Result {
+	res := &Result{Seq: t.seq, Timestamp: time.Now(), Intended: t.intended}
+	defer func() {
+		res.Latency = time.Since(res.Timestamp)
+	}()
+
+	tgt, err := tr()
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Target = tgt.Name
+
+	send(ctx, a.client, tgt.Request, res, io.Discard)
+	return res
+}
+
+// send sends req and records the response in res, its body is copied to
+// body. The request is not cancelled with ctx, it finishes within the
+// timeout of the client.
+func send(ctx context.Context, client *http.Client, req apiview.Request, res *Result, body io.Writer) {
+	hreq, err := req.HTTPRequest(context.WithoutCancel(ctx))
+	if err != nil {
+		res.Error = err.Error()
+		return
+	}
+	res.BytesOut = uint64(len(req.Body))
+
+	resp, err := client.Do(hreq)
+	if err != nil {
+		res.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	n, err := io.Copy(body, resp.Body)
+	res.BytesIn = uint64(n)
+	res.Code = uint16(resp.StatusCode)
+	if err != nil {
+		res.Error = err.Error()
+	} else if !res.Success() {
+		res.Error = resp.Status
+	}
+}
+
+// sleep waits for d, it returns false if ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
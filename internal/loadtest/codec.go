@@ -0,0 +1,442 @@
+package loadtest
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Encodings of results
+const (
+	EncodingBinary = "binary"
+	EncodingJSON   = "json"
+	EncodingCSV    = "csv"
+)
+
+// Encoder writes results to a stream, every result is written right away so
+// it can be read on the other end of a pipe
+type Encoder interface {
+	Encode(r *Result) error
+}
+
+// Decoder reads results from a stream, it returns io.EOF at the end
+type Decoder interface {
+	Decode(r *Result) error
+}
+
+// NewEncoder creates an encoder of the given encoding. The binary encoding
+// starts with a header and has a record per result prefixed with its length,
+// see appendRecord. JSON has one object per line. CSV has one row without
+// header per result, see csvColumns.
+func NewEncoder(w io.Writer, encoding string) (Encoder, error) {
+	switch encoding {
+	case EncodingBinary:
+		return &binaryEncoder{w: w}, nil
+	case EncodingJSON:
+		return jsonEncoder{json.NewEncoder(w)}, nil
+	case EncodingCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown encoding %q, use %s, %s or %s", encoding, EncodingBinary, EncodingJSON, EncodingCSV)
+}
+
+// NewDecoder creates a decoder for the encoding the stream starts with
+func NewDecoder(r io.Reader) Decoder {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(1)
+	switch {
+	case err != nil:
+		return errDecoder{err}
+	case first[0] == 0:
+		return &binaryDecoder{r: br}
+	case first[0] == '{':
+		return &jsonDecoder{d: json.NewDecoder(br)}
+	}
+	return &csvDecoder{r: csv.NewReader(br)}
+}
+
+// binaryHeader starts a binary stream, the zero length can't be a record.
+// Concatenated streams repeat it.
+var binaryHeader = []byte{0, 'R', 'Q', 'L', 1}
+
+type binaryEncoder struct {
+	w      io.Writer
+	header bool
+	buf    []byte
+	record []byte
+}
+
+func (e *binaryEncoder) Encode(r *Result) error {
+	b := appendRecord(e.record[:0], r)
+	e.record = b
+
+	out := e.buf[:0]
+	if !e.header {
+		out = append(out, binaryHeader...)
+		e.header = true
+	}
+	out = binary.AppendUvarint(out, uint64(len(b)))
+	out = append(out, b...)
+	e.buf = out
+
+	_, err := e.w.Write(out)
+	return err
+}
+
+// appendRecord appends the binary record of r. It holds the sequence number,
+// timestamp in Unix nanoseconds, latency in nanoseconds, status code, bytes
+// in and bytes out as varints, followed by the target and error as length
+// prefixed strings and the delay of the intended time in nanoseconds.
+func appendRecord(b []byte, r *Result) []byte {
+	b = binary.AppendUvarint(b, r.Seq)
+	b = binary.AppendVarint(b, r.Timestamp.UnixNano())
+	b = binary.AppendVarint(b, int64(r.Latency))
+	b = binary.AppendUvarint(b, uint64(r.Code))
+	b = binary.AppendUvarint(b, r.BytesIn)
+	b = binary.AppendUvarint(b, r.BytesOut)
+	b = appendString(b, r.Target)
+	b = appendString(b, r.Error)
+	return binary.AppendVarint(b, int64(r.Delay()))
+}
+
+// readRecord reads a record written by appendRecord into r, it has to hold
+// all fields and nothing else
+func readRecord(b []byte, r *Result) error {
+	rr := recordReader{r: bytes.NewReader(b)}
+	*r = Result{
+		Seq:       rr.uvarint(),
+		Timestamp: time.Unix(0, rr.varint()),
+		Latency:   time.Duration(rr.varint()),
+		Code:      uint16(rr.uvarint()),
+		BytesIn:   rr.uvarint(),
+		BytesOut:  rr.uvarint(),
+		Target:    rr.string(),
+		Error:     rr.string(),
+	}
+	r.Intended = r.Timestamp.Add(-time.Duration(rr.varint()))
+	if rr.err != nil {
+		return unexpectedEOF(rr.err)
+	}
+	if n := rr.r.Len(); n > 0 {
+		return fmt.Errorf("%d bytes after the last field", n)
+	}
+	return nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+type binaryDecoder struct {
+	r      *bufio.Reader
+	record []byte
+}
+
+func (d *binaryDecoder) Decode(r *Result) error {
+	size, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return err
+	}
+	for size == 0 {
+		// The header of a stream
+		header := make([]byte, len(binaryHeader)-1)
+		if _, err := io.ReadFull(d.r, header); err != nil {
+			return unexpectedEOF(err)
+		}
+		if !bytes.Equal(header, binaryHeader[1:]) {
+			return fmt.Errorf("no binary results")
+		}
+		if size, err = binary.ReadUvarint(d.r); err != nil {
+			return err
+		}
+	}
+
+	if cap(d.record) < int(size) {
+		d.record = make([]byte, size)
+	}
+	b := d.record[:size]
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return unexpectedEOF(err)
+	}
+
+	if err := readRecord(b, r); err != nil {
+		return fmt.Errorf("corrupt binary result: %w", err)
+	}
+	return nil
+}
+
+// recordReader reads the fields of a binary record, the first error sticks
+type recordReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (rr *recordReader) uvarint() uint64 {
+	if rr.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(rr.r)
+	rr.err = err
+	return v
+}
+
+func (rr *recordReader) varint() int64 {
+	if rr.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(rr.r)
+	rr.err = err
+	return v
+}
+
+func (rr *recordReader) string() string {
+	n := rr.uvarint()
+	if rr.err != nil {
+		return ""
+	}
+	if n > uint64(rr.r.Len()) {
+		rr.err = io.ErrUnexpectedEOF
+		return ""
+	}
+	b := make([]byte, n)
+	rr.r.Read(b)
+	return string(b)
+}
+
+// unexpectedEOF turns io.EOF in the middle of a record into an error
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+type jsonEncoder struct {
+	e *json.Encoder
+}
+
+func (e jsonEncoder) Encode(r *Result) error {
+	return e.e.Encode(r)
+}
+
+type jsonDecoder struct {
+	d *json.Decoder
+}
+
+func (d *jsonDecoder) Decode(r *Result) error {
+	*r = Result{}
+	return d.d.Decode(r)
+}
+
+// csvColumns are the columns of a CSV result, times are in Unix nanoseconds
+// and the latency in nanoseconds
+var csvColumns = []string{"timestamp", "code", "latency", "bytes_out", "bytes_in", "error", "target", "seq", "intended"}
+
+// csvRow returns the columns of r
+func csvRow(r *Result) []string {
+	return []string{
+		strconv.FormatInt(r.Timestamp.UnixNano(), 10),
+		strconv.Itoa(int(r.Code)),
+		strconv.FormatInt(int64(r.Latency), 10),
+		strconv.FormatUint(r.BytesOut, 10),
+		strconv.FormatUint(r.BytesIn, 10),
+		r.Error,
+		r.Target,
+		strconv.FormatUint(r.Seq, 10),
+		strconv.FormatInt(r.Timestamp.Add(-r.Delay()).UnixNano(), 10),
+	}
+}
+
+// parseCSVRow reads a row written by csvRow into r
+func parseCSVRow(rec []string, r *Result) error {
+	if len(rec) != len(csvColumns) {
+		return fmt.Errorf("%d columns instead of %d", len(rec), len(csvColumns))
+	}
+
+	var (
+		parseErr error
+		integer  = func(s string) int64 {
+			v, err := strconv.ParseInt(s, 10, 64)
+			if parseErr == nil {
+				parseErr = err
+			}
+			return v
+		}
+	)
+	*r = Result{
+		Timestamp: time.Unix(0, integer(rec[0])),
+		Code:      uint16(integer(rec[1])),
+		Latency:   time.Duration(integer(rec[2])),
+		BytesOut:  uint64(integer(rec[3])),
+		BytesIn:   uint64(integer(rec[4])),
+		Error:     rec[5],
+		Target:    rec[6],
+		Seq:       uint64(integer(rec[7])),
+		Intended:  time.Unix(0, integer(rec[8])),
+	}
+	return parseErr
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) Encode(r *Result) error {
+	if err := e.w.Write(csvRow(r)); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type csvDecoder struct {
+	r *csv.Reader
+}
+
+func (d *csvDecoder) Decode(r *Result) error {
+	rec, err := d.r.Read()
+	if err != nil {
+		return err
+	}
+	if err := parseCSVRow(rec, r); err != nil {
+		return fmt.Errorf("corrupt csv result: %w", err)
+	}
+	return nil
+}
+
+type errDecoder struct {
+	err error
+}
+
+func (d errDecoder) Decode(*Result) error {
+	return d.err
+}
+
+// MergeWindow is how far the results of a stream are sorted by Merge. The
+// attacker writes a result once its response is in, so it is behind the
+// results sent after it by at most its latency, which the timeout bounds.
+const MergeWindow = DefaultTimeout
+
+// Merge reads the results of several decoders ordered by their timestamp.
+// Every stream is in the order the requests completed, like the attacker
+// writes them, and is sorted within MergeWindow first. Only results slower
+// than that, sent with a longer timeout, may stay out of order.
+func Merge(decs ...Decoder) Decoder {
+	sorted := make([]Decoder, len(decs))
+	for i, d := range decs {
+		sorted[i] = &windowDecoder{dec: d, window: MergeWindow}
+	}
+	return &mergeDecoder{decs: sorted}
+}
+
+// windowDecoder sorts the results of dec by timestamp. A result is held back
+// until a result completing window after its timestamp has been read, no
+// result still to come can have been sent before it then.
+type windowDecoder struct {
+	dec    Decoder
+	window time.Duration
+	buf    resultHeap
+	done   time.Time // Latest completion read so far
+	eof    bool
+}
+
+func (w *windowDecoder) Decode(r *Result) error {
+	for !w.eof && (len(w.buf) == 0 || w.buf[0].Timestamp.After(w.done.Add(-w.window))) {
+		var res Result
+		err := w.dec.Decode(&res)
+		if errors.Is(err, io.EOF) {
+			w.eof = true
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if end := res.Timestamp.Add(res.Latency); end.After(w.done) {
+			w.done = end
+		}
+		heap.Push(&w.buf, &res)
+	}
+
+	if len(w.buf) == 0 {
+		return io.EOF
+	}
+	*r = *heap.Pop(&w.buf).(*Result)
+	return nil
+}
+
+// resultHeap orders results by timestamp, then sequence number
+type resultHeap []*Result
+
+func (h resultHeap) Len() int { return len(h) }
+
+func (h resultHeap) Less(i, j int) bool {
+	if h[i].Timestamp.Equal(h[j].Timestamp) {
+		return h[i].Seq < h[j].Seq
+	}
+	return h[i].Timestamp.Before(h[j].Timestamp)
+}
+
+func (h resultHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *resultHeap) Push(x interface{}) { *h = append(*h, x.(*Result)) }
+
+func (h *resultHeap) Pop() interface{} {
+	old := *h
+	r := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return r
+}
+
+type mergeDecoder struct {
+	decs  []Decoder
+	heads []*Result // Next result of every decoder, nil once it is done
+}
+
+func (m *mergeDecoder) Decode(r *Result) error {
+	if m.heads == nil {
+		m.heads = make([]*Result, len(m.decs))
+		for i := range m.decs {
+			if err := m.next(i); err != nil {
+				return err
+			}
+		}
+	}
+
+	first := -1
+	for i, h := range m.heads {
+		if h != nil && (first < 0 || h.Timestamp.Before(m.heads[first].Timestamp)) {
+			first = i
+		}
+	}
+	if first < 0 {
+		return io.EOF
+	}
+
+	*r = *m.heads[first]
+	return m.next(first)
+}
+
+// next reads the next result of decoder i
+func (m *mergeDecoder) next(i int) error {
+	var res Result
+	err := m.decs[i].Decode(&res)
+	if errors.Is(err, io.EOF) {
+		m.heads[i] = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	m.heads[i] = &res
+	return nil
+}
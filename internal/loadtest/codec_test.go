@@ -0,0 +1,139 @@
+package loadtest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// encodeResults writes results in the given order with encoding
+func encodeResults(t *testing.T, encoding string, results []Result) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf, encoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range results {
+		if err := enc.Encode(&results[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &buf
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	start := time.Unix(1700000000, 123)
+	results := []Result{
+		{Seq: 0, Target: "getUser", Timestamp: start, Intended: start, Latency: 5 * time.Millisecond, Code: 200, BytesIn: 512, BytesOut: 64},
+		{Seq: 1, Target: "GET http://localhost/a,b", Timestamp: start.Add(time.Second), Intended: start.Add(900 * time.Millisecond), Latency: time.Second, Code: 500, Error: "500 Internal Server Error"},
+		{Seq: 2, Target: "createUser", Timestamp: start.Add(2 * time.Second), Intended: start.Add(2 * time.Second), Error: `Post "http://localhost": connection refused`},
+	}
+
+	for _, encoding := range []string{EncodingBinary, EncodingJSON, EncodingCSV} {
+		t.Run(encoding, func(t *testing.T) {
+			dec := NewDecoder(encodeResults(t, encoding, results))
+			for i := range results {
+				var r Result
+				if err := dec.Decode(&r); err != nil {
+					t.Fatal(err)
+				}
+				if !r.Timestamp.Equal(results[i].Timestamp) || !r.Intended.Equal(results[i].Intended) {
+					t.Errorf("result %d sent at %s, due at %s, want %s and %s", i, r.Timestamp, r.Intended, results[i].Timestamp, results[i].Intended)
+				}
+				r.Timestamp, r.Intended = results[i].Timestamp, results[i].Intended
+				if !reflect.DeepEqual(r, results[i]) {
+					t.Errorf("got  %+v\nwant %+v", r, results[i])
+				}
+			}
+			var r Result
+			if err := dec.Decode(&r); !errors.Is(err, io.EOF) {
+				t.Errorf("got %v after the last result, want EOF", err)
+			}
+		})
+	}
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	r := Result{Seq: 1, Target: "t", Timestamp: time.Unix(1700000000, 0), Latency: time.Millisecond, Code: 200}
+	record := appendRecord(nil, &r)
+
+	// withLength prefixes a record with its length, after the header
+	withLength := func(record []byte) io.Reader {
+		b := append([]byte{}, binaryHeader...)
+		b = binary.AppendUvarint(b, uint64(len(record)))
+		return bytes.NewReader(append(b, record...))
+	}
+	// The delay is a single byte for zero
+	short := record[:len(record)-1]
+
+	tests := map[string]io.Reader{
+		"binary without the delay": withLength(short),
+		"binary with extra bytes":  withLength(append(append([]byte{}, record...), 0)),
+		"binary cut off":           bytes.NewReader(encodeResults(t, EncodingBinary, []Result{r}).Bytes()[:len(binaryHeader)+4]),
+		"binary bad header":        bytes.NewReader([]byte{0, 'X', 'Y', 'Z', 1, 1, 0}),
+		"csv without intended":     strings.NewReader("1700000000000000000,200,1000000,0,0,,t,1\n"),
+		"csv with a bad number":    strings.NewReader("1700000000000000000,ok,1000000,0,0,,t,1,1700000000000000000\n"),
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got Result
+			err := NewDecoder(input).Decode(&got)
+			if err == nil || errors.Is(err, io.EOF) {
+				t.Errorf("got %v, want an error", err)
+			}
+		})
+	}
+}
+
+func TestMergeOrdersByTimestamp(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	at := func(seq uint64, sent, latency time.Duration) Result {
+		return Result{Seq: seq, Target: "t", Timestamp: start.Add(sent), Intended: start.Add(sent), Latency: latency, Code: 200}
+	}
+
+	// In the order they completed, like the attacker writes them
+	a := []Result{
+		at(1, 10*time.Millisecond, 5*time.Millisecond),
+		at(2, 20*time.Millisecond, 5*time.Millisecond),
+		at(0, 0, time.Second),
+		at(3, 2*time.Second, 10*time.Millisecond),
+	}
+	b := []Result{
+		at(5, 15*time.Millisecond, 2*time.Second),
+		at(4, 3*time.Second, time.Millisecond),
+	}
+
+	dec := Merge(
+		NewDecoder(encodeResults(t, EncodingBinary, a)),
+		NewDecoder(encodeResults(t, EncodingCSV, b)),
+	)
+
+	var got []uint64
+	for {
+		var r Result
+		err := dec.Decode(&r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r.Seq)
+	}
+
+	want := []uint64{0, 1, 5, 2, 3, 4}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
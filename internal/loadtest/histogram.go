@@ -0,0 +1,143 @@
+package loadtest
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// subBucketBits sets the precision of a Histogram, every power of two is
+// split into 2^subBucketBits buckets for a relative error below 1%
+const subBucketBits = 7
+
+const subBuckets = 1 << subBucketBits
+
+// Histogram records latencies in log-linear buckets like an HDR histogram,
+// its memory only grows with the largest latency and not the number of
+// values. Quantiles are exact up to the bucket width.
+type Histogram struct {
+	counts []uint64
+	total  uint64
+	sum    float64
+	min    time.Duration
+	max    time.Duration
+}
+
+// Record adds a latency
+func (h *Histogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+
+	i := bucketIndex(uint64(d))
+	if i >= len(h.counts) {
+		grown := make([]uint64, i+1)
+		copy(grown, h.counts)
+		h.counts = grown
+	}
+	h.counts[i]++
+
+	if h.total == 0 || d < h.min {
+		h.min = d
+	}
+	h.max = max(h.max, d)
+	h.total++
+	h.sum += float64(d)
+}
+
+// Merge adds all values of o
+func (h *Histogram) Merge(o *Histogram) {
+	if o.total == 0 {
+		return
+	}
+	if len(o.counts) > len(h.counts) {
+		grown := make([]uint64, len(o.counts))
+		copy(grown, h.counts)
+		h.counts = grown
+	}
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+
+	if h.total == 0 || o.min < h.min {
+		h.min = o.min
+	}
+	h.max = max(h.max, o.max)
+	h.total += o.total
+	h.sum += o.sum
+}
+
+// Count returns the number of recorded values
+func (h *Histogram) Count() uint64 {
+	return h.total
+}
+
+func (h *Histogram) Min() time.Duration {
+	return h.min
+}
+
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+// Mean is exact, it is not affected by the bucket width
+func (h *Histogram) Mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.sum / float64(h.total))
+}
+
+// Quantile returns the latency q of the values are at or below, e.g. 0.99
+// for the 99th percentile
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(h.total)))
+	rank = min(max(rank, 1), h.total)
+
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			d := time.Duration(bucketMiddle(i))
+			return min(max(d, h.min), h.max)
+		}
+	}
+	return h.max
+}
+
+// bucketIndex maps v to its bucket, values below subBuckets get a bucket
+// each, above every power of two has subBuckets buckets
+func bucketIndex(v uint64) int {
+	if v < subBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - subBucketBits - 1
+	return (shift+1)*subBuckets + int(v>>shift) - subBuckets
+}
+
+// bucketMiddle returns the value in the middle of bucket i
+func bucketMiddle(i int) uint64 {
+	group, offset := i/subBuckets, uint64(i%subBuckets)
+	if group == 0 {
+		return offset
+	}
+	shift := group - 1
+	lower := (subBuckets + offset) << shift
+	return lower + (1<<shift)/2
+}
+
+// above returns the number of values in buckets above d, consistent with
+// Quantile
+func (h *Histogram) above(d time.Duration) uint64 {
+	var n uint64
+	for i, c := range h.counts {
+		if c > 0 && min(max(time.Duration(bucketMiddle(i)), h.min), h.max) > d {
+			n += c
+		}
+	}
+	return n
+}
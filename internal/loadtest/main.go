@@ -1,7 +0,0 @@
-package loadtest
-
-import "fmt"
-
-func Main() {
-	fmt.Println("Starting loadtest...")
-}
@@ -0,0 +1,218 @@
+package loadtest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LateTolerance is how late a request may be sent before it counts as late,
+// timers and handing the request to a worker take a moment
+const LateTolerance = 10 * time.Millisecond
+
+// Metrics aggregates the results of an attack. Add results and call Close
+// before reading the fields.
+type Metrics struct {
+	Latencies     LatencyMetrics    `json:"latencies"`     // Service times
+	ResponseTimes LatencyMetrics    `json:"responseTimes"` // From the intended send time, see Result.ResponseTime
+	BytesIn       ByteMetrics       `json:"bytesIn"`
+	BytesOut      ByteMetrics       `json:"bytesOut"`
+	Earliest      time.Time         `json:"earliest"`
+	Latest        time.Time         `json:"latest"` // Start of the last request
+	End           time.Time         `json:"end"`    // End of the last request
+	Duration      time.Duration     `json:"duration"`
+	Wait          time.Duration     `json:"wait"` // For the last response after the last request was sent
+	Requests      uint64            `json:"requests"`
+	Rate          float64           `json:"rate"`       // Attempted requests per second
+	Throughput    float64           `json:"throughput"` // Successful requests per second
+	Success       float64           `json:"success"`    // Ratio of successful requests
+	StatusCodes   map[string]uint64 `json:"statusCodes"`
+	Errors        map[string]uint64 `json:"errors"`
+
+	// Late counts the requests sent more than LateTolerance after their
+	// intended time, the attacker fell behind its schedule for them
+	Late     uint64        `json:"late"`
+	MaxDelay time.Duration `json:"maxDelay"` // Of any request
+
+	// Buckets are the bounds of the latency histogram, each bucket counts
+	// the latencies from its bound up to the next one. They have to be set
+	// before adding results.
+	Buckets      Buckets  `json:"buckets,omitempty"`
+	BucketCounts []uint64 `json:"bucketCounts,omitempty"`
+
+	Histogram         Histogram `json:"-"`
+	ResponseHistogram Histogram `json:"-"`
+	success           uint64
+}
+
+// LatencyMetrics are the latency percentiles of an attack
+type LatencyMetrics struct {
+	Total time.Duration `json:"total"`
+	Min   time.Duration `json:"min"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"50th"`
+	P90   time.Duration `json:"90th"`
+	P95   time.Duration `json:"95th"`
+	P99   time.Duration `json:"99th"`
+	P999  time.Duration `json:"99.9th"`
+	Max   time.Duration `json:"max"`
+}
+
+// ByteMetrics are the bytes sent or received by an attack
+type ByteMetrics struct {
+	Total uint64  `json:"total"`
+	Mean  float64 `json:"mean"`
+}
+
+// Add aggregates a result
+func (m *Metrics) Add(r *Result) {
+	if m.StatusCodes == nil {
+		m.StatusCodes = map[string]uint64{}
+		m.Errors = map[string]uint64{}
+	}
+
+	m.Requests++
+	m.StatusCodes[strconv.Itoa(int(r.Code))]++
+	m.BytesIn.Total += r.BytesIn
+	m.BytesOut.Total += r.BytesOut
+	m.Histogram.Record(r.Latency)
+	m.Latencies.Total += r.Latency
+
+	rt := r.ResponseTime()
+	m.ResponseHistogram.Record(rt)
+	m.ResponseTimes.Total += rt
+	d := r.Delay()
+	if d > LateTolerance {
+		m.Late++
+	}
+	m.MaxDelay = max(m.MaxDelay, d)
+
+	if m.Earliest.IsZero() || r.Timestamp.Before(m.Earliest) {
+		m.Earliest = r.Timestamp
+	}
+	if r.Timestamp.After(m.Latest) {
+		m.Latest = r.Timestamp
+	}
+	if end := r.Timestamp.Add(r.Latency); end.After(m.End) {
+		m.End = end
+	}
+
+	if r.Success() {
+		m.success++
+	} else if r.Error != "" {
+		m.Errors[r.Error]++
+	}
+
+	if len(m.Buckets) > 0 {
+		if m.BucketCounts == nil {
+			m.BucketCounts = make([]uint64, len(m.Buckets))
+		}
+		if i := m.Buckets.index(r.Latency); i >= 0 {
+			m.BucketCounts[i]++
+		}
+	}
+}
+
+// Close computes the derived metrics
+func (m *Metrics) Close() {
+	if m.Requests == 0 {
+		return
+	}
+
+	m.Duration = m.Latest.Sub(m.Earliest)
+	m.Wait = m.End.Sub(m.Latest)
+	if secs := m.Duration.Seconds(); secs > 0 {
+		m.Rate = float64(m.Requests) / secs
+	}
+	if secs := (m.Duration + m.Wait).Seconds(); secs > 0 {
+		m.Throughput = float64(m.success) / secs
+	}
+	m.Success = float64(m.success) / float64(m.Requests)
+
+	m.BytesIn.Mean = float64(m.BytesIn.Total) / float64(m.Requests)
+	m.BytesOut.Mean = float64(m.BytesOut.Total) / float64(m.Requests)
+
+	m.Latencies.fill(&m.Histogram)
+	m.ResponseTimes.fill(&m.ResponseHistogram)
+}
+
+// fill sets the percentiles from h
+func (l *LatencyMetrics) fill(h *Histogram) {
+	l.Min = h.Min()
+	l.Mean = h.Mean()
+	l.P50 = h.Quantile(0.5)
+	l.P90 = h.Quantile(0.9)
+	l.P95 = h.Quantile(0.95)
+	l.P99 = h.Quantile(0.99)
+	l.P999 = h.Quantile(0.999)
+	l.Max = h.Max()
+}
+
+// Buckets are the ascending lower bounds of histogram buckets
+type Buckets []time.Duration
+
+// ParseBuckets parses bounds like "[0,10ms,50ms,100ms]", the brackets are
+// optional
+func ParseBuckets(s string) (Buckets, error) {
+	s = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(s), "["), "]")
+
+	var b Buckets
+	for _, part := range strings.Split(s, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid bucket %q: %w", part, err)
+		}
+		if len(b) > 0 && d <= b[len(b)-1] {
+			return nil, fmt.Errorf("buckets must be ascending, %s follows %s", d, b[len(b)-1])
+		}
+		b = append(b, d)
+	}
+	return b, nil
+}
+
+// index returns the bucket d falls into, -1 if it is below the first bound
+func (b Buckets) index(d time.Duration) int {
+	return sort.Search(len(b), func(i int) bool { return b[i] > d }) - 1
+}
+
+// ScenarioMetrics aggregates the results of a scenario in total and per step
+type ScenarioMetrics struct {
+	Total *Metrics      `json:"total"`
+	Steps []StepMetrics `json:"steps"`
+	steps map[string]int
+}
+
+// StepMetrics are the metrics of a single step of a scenario
+type StepMetrics struct {
+	Name string `json:"name"`
+	Metrics
+}
+
+// NewScenarioMetrics aggregates the results of sc in total and per step,
+// the steps use the buckets of total
+func NewScenarioMetrics(sc *Scenario, total *Metrics) *ScenarioMetrics {
+	m := &ScenarioMetrics{Total: total, steps: map[string]int{}}
+	for i, st := range sc.Steps {
+		m.Steps = append(m.Steps, StepMetrics{Name: st.Name, Metrics: Metrics{Buckets: total.Buckets}})
+		m.steps[st.Name] = i
+	}
+	return m
+}
+
+// Add aggregates a result in the total and its step
+func (m *ScenarioMetrics) Add(r *Result) {
+	m.Total.Add(r)
+	if i, ok := m.steps[r.Target]; ok {
+		m.Steps[i].Add(r)
+	}
+}
+
+// Close computes the derived metrics
+func (m *ScenarioMetrics) Close() {
+	m.Total.Close()
+	for i := range m.Steps {
+		m.Steps[i].Close()
+	}
+}
@@ -0,0 +1,233 @@
+package loadtest
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Pacer decides when the hits of an attack are sent
+type Pacer interface {
+	// Pace returns how long to wait before sending the next hit, given the
+	// time elapsed since the start and the hits sent so far. Stop reports
+	// that no more hits should be sent.
+	Pace(elapsed time.Duration, hits uint64) (wait time.Duration, stop bool)
+	// RateAt returns the rate in hits per second at elapsed
+	RateAt(elapsed time.Duration) float64
+}
+
+// Kinds of pacers for PacerOptions
+const (
+	PacerConstant = "constant"
+	PacerLinear   = "linear"
+	PacerSine     = "sine"
+	PacerSteps    = "steps"
+)
+
+// PacerKinds lists the kinds of pacers NewPacer knows
+var PacerKinds = []string{PacerConstant, PacerLinear, PacerSine, PacerSteps}
+
+// PacerOptions describe a pacer, only the fields of Kind are used
+type PacerOptions struct {
+	Kind      string
+	Rate      Rate          // Constant rate, start of the ramp or mean of the sine
+	RateTo    Rate          // End of the ramp
+	Ramp      time.Duration // Duration of the ramp
+	Amplitude Rate          // Of the sine
+	Period    time.Duration // Of the sine
+	Steps     []Step
+}
+
+// NewPacer creates the pacer described by o
+func NewPacer(o PacerOptions) (Pacer, error) {
+	switch o.Kind {
+	case "", PacerConstant:
+		return o.Rate, nil
+	case PacerLinear:
+		if o.Ramp <= 0 {
+			return nil, fmt.Errorf("linear pacer needs a ramp duration")
+		}
+		return LinearPacer{From: o.Rate, To: o.RateTo, Duration: o.Ramp}, nil
+	case PacerSine:
+		if o.Period <= 0 {
+			return nil, fmt.Errorf("sine pacer needs a period")
+		}
+		if o.Amplitude.PerSecond() > o.Rate.PerSecond() {
+			return nil, fmt.Errorf("sine pacer amplitude %s is larger than the mean rate %s", o.Amplitude, o.Rate)
+		}
+		return SinePacer{Mean: o.Rate, Amplitude: o.Amplitude, Period: o.Period}, nil
+	case PacerSteps:
+		if len(o.Steps) == 0 {
+			return nil, fmt.Errorf("steps pacer needs at least one step")
+		}
+		return StepPacer(o.Steps), nil
+	}
+	return nil, fmt.Errorf("unknown pacer %q, use one of %s", o.Kind, strings.Join(PacerKinds, ", "))
+}
+
+// LinearPacer ramps the rate linearly from From to To over Duration and
+// keeps sending at To afterwards
+type LinearPacer struct {
+	From     Rate
+	To       Rate
+	Duration time.Duration
+}
+
+func (p LinearPacer) RateAt(elapsed time.Duration) float64 {
+	if elapsed >= p.Duration {
+		return p.To.PerSecond()
+	}
+	from := p.From.PerSecond()
+	return from + (p.To.PerSecond()-from)*elapsed.Seconds()/p.Duration.Seconds()
+}
+
+func (p LinearPacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	return paceHits(p.hitsAt, elapsed, hits)
+}
+
+// hitsAt is the integral of the rate
+func (p LinearPacer) hitsAt(t time.Duration) float64 {
+	from, to := p.From.PerSecond(), p.To.PerSecond()
+	d := p.Duration.Seconds()
+
+	ramp := min(t.Seconds(), d)
+	hits := from*ramp + (to-from)*ramp*ramp/(2*d)
+	if t.Seconds() > d {
+		hits += to * (t.Seconds() - d)
+	}
+	return hits
+}
+
+// SinePacer sends at Mean plus a sine wave of Amplitude and Period, starting
+// at the mean on the rising edge
+type SinePacer struct {
+	Mean      Rate
+	Amplitude Rate
+	Period    time.Duration
+}
+
+func (p SinePacer) RateAt(elapsed time.Duration) float64 {
+	return p.Mean.PerSecond() + p.Amplitude.PerSecond()*math.Sin(p.phase(elapsed))
+}
+
+func (p SinePacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	return paceHits(p.hitsAt, elapsed, hits)
+}
+
+func (p SinePacer) phase(t time.Duration) float64 {
+	return 2 * math.Pi * t.Seconds() / p.Period.Seconds()
+}
+
+// hitsAt is the integral of the rate
+func (p SinePacer) hitsAt(t time.Duration) float64 {
+	wave := p.Amplitude.PerSecond() * p.Period.Seconds() / (2 * math.Pi) * (1 - math.Cos(p.phase(t)))
+	return p.Mean.PerSecond()*t.Seconds() + wave
+}
+
+// Step is a stage of a StepPacer
+type Step struct {
+	Rate     Rate
+	Duration time.Duration
+}
+
+// ParseStep parses a step like "100/s:1m", a rate followed by its duration
+func ParseStep(s string) (Step, error) {
+	rate, duration, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return Step{}, fmt.Errorf("invalid step %q, expected rate:duration like 100/s:1m", s)
+	}
+
+	r, err := ParseRate(rate)
+	if err != nil {
+		return Step{}, err
+	}
+	d, err := time.ParseDuration(duration)
+	if err != nil || d <= 0 {
+		return Step{}, fmt.Errorf("invalid step %q: duration must be a positive duration", s)
+	}
+	return Step{Rate: r, Duration: d}, nil
+}
+
+func (s Step) String() string {
+	return s.Rate.String() + ":" + s.Duration.String()
+}
+
+// StepPacer sends at the rate of every step for its duration, one after the
+// other, and stops after the last one
+type StepPacer []Step
+
+func (p StepPacer) RateAt(elapsed time.Duration) float64 {
+	for _, s := range p {
+		if elapsed < s.Duration {
+			return s.Rate.PerSecond()
+		}
+		elapsed -= s.Duration
+	}
+	return 0
+}
+
+func (p StepPacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	if elapsed >= p.duration() {
+		return 0, true
+	}
+
+	wait, stop := paceHits(p.hitsAt, elapsed, hits)
+	if stop || elapsed+wait >= p.duration() {
+		return 0, true
+	}
+	return wait, false
+}
+
+func (p StepPacer) duration() time.Duration {
+	var d time.Duration
+	for _, s := range p {
+		d += s.Duration
+	}
+	return d
+}
+
+// hitsAt is the integral of the rate
+func (p StepPacer) hitsAt(t time.Duration) float64 {
+	var hits float64
+	for _, s := range p {
+		step := min(t, s.Duration)
+		hits += s.Rate.PerSecond() * step.Seconds()
+		t -= step
+		if t <= 0 {
+			break
+		}
+	}
+	return hits
+}
+
+// maxPaceWait is the longest a pacer waits for a hit, hits due later are
+// never sent
+const maxPaceWait = 24 * time.Hour
+
+// paceHits computes the wait until the next hit for a pacer whose rate is
+// integrated into hitsAt, the number of hits due by t. Hit n is due once
+// hitsAt reaches n, the time is found by bisection to the microsecond.
+func paceHits(hitsAt func(t time.Duration) float64, elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	due := float64(hits)
+	if hitsAt(elapsed) >= due {
+		return 0, false
+	}
+
+	lo, hi := elapsed, elapsed+time.Millisecond
+	for hitsAt(hi) < due {
+		if hi-elapsed > maxPaceWait {
+			return 0, true
+		}
+		lo, hi = hi, elapsed+2*(hi-elapsed)
+	}
+	for hi-lo > time.Microsecond {
+		mid := lo + (hi-lo)/2
+		if hitsAt(mid) >= due {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi - elapsed, false
+}
@@ -0,0 +1,112 @@
+package loadtest
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// simulate drives p with a fake clock until it stops or until is reached
+// and returns the hits sent and the time of the last one. Every hit is sent
+// the moment it is due.
+func simulate(p Pacer, until time.Duration) (hits uint64, last time.Duration, stopped bool) {
+	var elapsed time.Duration
+	for {
+		wait, stop := p.Pace(elapsed, hits)
+		if stop {
+			return hits, last, true
+		}
+		if elapsed+wait >= until {
+			return hits, last, false
+		}
+		elapsed += wait
+		if wait == 0 {
+			hits++
+			last = elapsed
+		}
+	}
+}
+
+func TestPacerHits(t *testing.T) {
+	perSecond := func(n int) Rate { return Rate{Freq: n, Per: time.Second} }
+
+	tests := []struct {
+		name  string
+		pacer Pacer
+		until time.Duration
+		want  uint64
+	}{
+		{"constant", perSecond(100), time.Second, 100},
+		{"constant per minute", Rate{Freq: 60, Per: time.Minute}, 10 * time.Second, 10},
+		{"constant fractional interval", Rate{Freq: 3, Per: time.Second}, 2 * time.Second, 6},
+		{"linear ramp up", LinearPacer{From: perSecond(0), To: perSecond(100), Duration: 10 * time.Second}, 10 * time.Second, 500},
+		{"linear ramp down", LinearPacer{From: perSecond(100), To: perSecond(0), Duration: 10 * time.Second}, 10 * time.Second, 500},
+		{"linear after the ramp", LinearPacer{From: perSecond(0), To: perSecond(100), Duration: 10 * time.Second}, 20 * time.Second, 1500},
+		{"sine full period", SinePacer{Mean: perSecond(100), Amplitude: perSecond(50), Period: 10 * time.Second}, 10 * time.Second, 1000},
+		// The rising half adds the area of the wave, 50*10/pi
+		{"sine half period", SinePacer{Mean: perSecond(100), Amplitude: perSecond(50), Period: 10 * time.Second}, 5 * time.Second, 660},
+		{"steps", StepPacer{{perSecond(10), time.Second}, {perSecond(100), 2 * time.Second}}, time.Minute, 210},
+		{"steps with a pause", StepPacer{{perSecond(10), time.Second}, {Rate{}, time.Second}, {perSecond(10), time.Second}}, time.Minute, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, _ := simulate(tt.pacer, tt.until)
+			// The bisection rounds to the microsecond, a hit due right at
+			// the end may fall on either side
+			if diff := math.Abs(float64(got) - float64(tt.want)); diff > 1 {
+				t.Errorf("%d hits in %s, want %d", got, tt.until, tt.want)
+			}
+		})
+	}
+}
+
+func TestStepPacerStops(t *testing.T) {
+	p := StepPacer{{Rate{Freq: 10, Per: time.Second}, time.Second}, {Rate{Freq: 20, Per: time.Second}, time.Second}}
+
+	hits, last, stopped := simulate(p, time.Hour)
+	if !stopped {
+		t.Fatal("did not stop after the last step")
+	}
+	if hits != 30 {
+		t.Errorf("%d hits, want 30", hits)
+	}
+	if last >= p.duration() {
+		t.Errorf("last hit at %s, after the steps ended at %s", last, p.duration())
+	}
+
+	if _, stop := p.Pace(2*time.Second, 0); !stop {
+		t.Error("Pace at the end of the steps did not stop, even with hits due")
+	}
+	if got := p.RateAt(2 * time.Second); got != 0 {
+		t.Errorf("rate after the steps is %v, want 0", got)
+	}
+}
+
+func TestPaceCatchesUp(t *testing.T) {
+	pacers := map[string]Pacer{
+		"constant": Rate{Freq: 10, Per: time.Second},
+		"linear":   LinearPacer{From: Rate{Freq: 10, Per: time.Second}, To: Rate{Freq: 10, Per: time.Second}, Duration: time.Second},
+		"sine":     SinePacer{Mean: Rate{Freq: 10, Per: time.Second}, Period: time.Second},
+		"steps":    StepPacer{{Rate{Freq: 10, Per: time.Second}, time.Minute}},
+	}
+	for name, p := range pacers {
+		t.Run(name, func(t *testing.T) {
+			// Behind by five hits, they are due at once
+			if wait, stop := p.Pace(time.Second, 5); wait != 0 || stop {
+				t.Errorf("Pace behind = %s, %v, want 0, false", wait, stop)
+			}
+			// Ahead by one hit, it is due in 100ms
+			wait, stop := p.Pace(time.Second, 11)
+			if stop || wait < 100*time.Millisecond-time.Microsecond || wait > 100*time.Millisecond+time.Microsecond {
+				t.Errorf("Pace ahead = %s, %v, want 100ms, false", wait, stop)
+			}
+		})
+	}
+}
+
+func TestPaceGivesUpOnHitsTooFarAhead(t *testing.T) {
+	p := LinearPacer{From: Rate{Freq: 1, Per: time.Second}, To: Rate{}, Duration: time.Second}
+	if _, stop := p.Pace(0, 2); !stop {
+		t.Error("Pace waits for a hit that is never due")
+	}
+}
@@ -0,0 +1,95 @@
+package loadtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate is a constant number of hits per time period, the zero rate sends
+// as fast as the workers can. It is the constant Pacer.
+type Rate struct {
+	Freq int
+	Per  time.Duration
+}
+
+// ParseRate parses rates like "100/s", "5/100ms" or "300/1m", a plain
+// number is per second and "0" or "max" is the zero rate
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	if s == "max" {
+		return Rate{}, nil
+	}
+
+	freq, per, ok := strings.Cut(s, "/")
+	r := Rate{Per: time.Second}
+
+	var err error
+	r.Freq, err = strconv.Atoi(freq)
+	if err != nil || r.Freq < 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q: frequency must be a positive number", s)
+	}
+
+	if ok {
+		// A unit without a number, e.g. "s", is one of it
+		if per != "" && (per[0] < '0' || per[0] > '9') {
+			per = "1" + per
+		}
+		r.Per, err = time.ParseDuration(per)
+		if err != nil || r.Per <= 0 {
+			return Rate{}, fmt.Errorf("invalid rate %q: period must be a positive duration", s)
+		}
+	}
+
+	return r, nil
+}
+
+func (r Rate) String() string {
+	if r.IsZero() {
+		return "max"
+	}
+
+	per := r.Per.String()
+	switch r.Per {
+	case time.Second:
+		per = "s"
+	case time.Minute:
+		per = "m"
+	case time.Hour:
+		per = "h"
+	}
+	return fmt.Sprintf("%d/%s", r.Freq, per)
+}
+
+// IsZero reports whether r paces at all
+func (r Rate) IsZero() bool {
+	return r.Freq == 0 || r.Per == 0
+}
+
+// PerSecond returns the rate in hits per second
+func (r Rate) PerSecond() float64 {
+	if r.IsZero() {
+		return 0
+	}
+	return float64(r.Freq) / r.Per.Seconds()
+}
+
+// RateAt is the constant rate in hits per second
+func (r Rate) RateAt(time.Duration) float64 {
+	return r.PerSecond()
+}
+
+// Pace makes Rate a constant Pacer, it never stops on its own
+func (r Rate) Pace(elapsed time.Duration, hits uint64) (wait time.Duration, stop bool) {
+	if r.IsZero() {
+		return 0, false
+	}
+
+	interval := float64(r.Per) / float64(r.Freq)
+	due := time.Duration(interval * float64(hits))
+	if due <= elapsed {
+		return 0, false
+	}
+	return due - elapsed, false
+}
@@ -0,0 +1,160 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bata94/reqlab/internal/request"
+)
+
+// Types of reports
+const (
+	ReportText      = "text"
+	ReportJSON      = "json"
+	ReportHistogram = "hist"
+)
+
+// WriteReport writes m as a report of the given type, a histogram report
+// needs the buckets of m to be set
+func WriteReport(w io.Writer, m *Metrics, typ string) error {
+	switch typ {
+	case ReportText:
+		return WriteText(w, m)
+	case ReportJSON:
+		return WriteJSON(w, m)
+	case ReportHistogram:
+		return WriteHistogram(w, m)
+	}
+	return fmt.Errorf("unknown report %q, use %s, %s or %s", typ, ReportText, ReportJSON, ReportHistogram)
+}
+
+// WriteText writes m as a table like Vegeta's text report. Latencies are the
+// service times, response times count from the intended send time.
+func WriteText(w io.Writer, m *Metrics) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	l := m.Latencies
+	d := request.FormatDuration
+
+	fmt.Fprintf(tw, "Requests\t[total, rate, throughput]\t%d, %.2f, %.2f\n", m.Requests, m.Rate, m.Throughput)
+	fmt.Fprintf(tw, "Duration\t[total, attack, wait]\t%s, %s, %s\n", d(m.Duration+m.Wait), d(m.Duration), d(m.Wait))
+	fmt.Fprintf(tw, "Latencies\t[min, mean, 50, 90, 95, 99, 99.9, max]\t%s, %s, %s, %s, %s, %s, %s, %s\n",
+		d(l.Min), d(l.Mean), d(l.P50), d(l.P90), d(l.P95), d(l.P99), d(l.P999), d(l.Max))
+	rt := m.ResponseTimes
+	fmt.Fprintf(tw, "Response Times\t[min, mean, 50, 90, 95, 99, 99.9, max]\t%s, %s, %s, %s, %s, %s, %s, %s\n",
+		d(rt.Min), d(rt.Mean), d(rt.P50), d(rt.P90), d(rt.P95), d(rt.P99), d(rt.P999), d(rt.Max))
+	fmt.Fprintf(tw, "Bytes In\t[total, mean]\t%s, %.2f\n", request.FormatSize(int(m.BytesIn.Total)), m.BytesIn.Mean)
+	fmt.Fprintf(tw, "Bytes Out\t[total, mean]\t%s, %.2f\n", request.FormatSize(int(m.BytesOut.Total)), m.BytesOut.Mean)
+	fmt.Fprintf(tw, "Success\t[ratio]\t%.2f%%\n", m.Success*100)
+
+	codes := make([]string, 0, len(m.StatusCodes))
+	for code, n := range m.StatusCodes {
+		codes = append(codes, code+":"+strconv.FormatUint(n, 10))
+	}
+	sort.Strings(codes)
+	fmt.Fprintf(tw, "Status Codes\t[code:count]\t%s\n", strings.Join(codes, "  "))
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if m.Late > 0 {
+		fmt.Fprintf(w, "Warning: %d requests (%.2f%%) were sent late, up to %s behind schedule. The attacker ran out of workers, latencies measure the service time, response times include the delay.\n",
+			m.Late, float64(m.Late)/float64(m.Requests)*100, d(m.MaxDelay))
+	}
+
+	if len(m.Errors) == 0 {
+		return nil
+	}
+
+	// Most frequent errors first
+	errs := make([]string, 0, len(m.Errors))
+	for e := range m.Errors {
+		errs = append(errs, e)
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		if m.Errors[errs[i]] != m.Errors[errs[j]] {
+			return m.Errors[errs[i]] > m.Errors[errs[j]]
+		}
+		return errs[i] < errs[j]
+	})
+
+	fmt.Fprintln(w, "Error Set:")
+	for _, e := range errs {
+		fmt.Fprintf(w, "%6d  %s\n", m.Errors[e], e)
+	}
+	return nil
+}
+
+// WriteJSON writes m as JSON, durations are in nanoseconds
+func WriteJSON(w io.Writer, m *Metrics) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(m)
+}
+
+// histogramBarWidth is the width of a bar for 100% in a histogram report
+const histogramBarWidth = 50
+
+// WriteHistogram writes the counts of the buckets of m with a bar each
+func WriteHistogram(w io.Writer, m *Metrics) error {
+	if len(m.Buckets) == 0 {
+		return fmt.Errorf("histogram report needs buckets")
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "Bucket\t\t#\t%\tHistogram")
+	for i, lower := range m.Buckets {
+		upper := "+Inf"
+		if i+1 < len(m.Buckets) {
+			upper = m.Buckets[i+1].String()
+		}
+
+		var count uint64
+		if i < len(m.BucketCounts) {
+			count = m.BucketCounts[i]
+		}
+		ratio := 0.0
+		if m.Requests > 0 {
+			ratio = float64(count) / float64(m.Requests)
+		}
+
+		fmt.Fprintf(tw, "[%s,\t%s]\t%d\t%.2f%%\t%s\n",
+			lower, upper, count, ratio*100, strings.Repeat("#", int(ratio*histogramBarWidth)))
+	}
+	return tw.Flush()
+}
+
+// WriteScenarioReport writes the total of m as a report of the given type
+// followed by a table of the steps, JSON has the steps in the same object
+func WriteScenarioReport(w io.Writer, m *ScenarioMetrics, typ string) error {
+	if typ == ReportJSON {
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(m)
+	}
+
+	if err := WriteReport(w, m.Total, typ); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	return WriteSteps(w, m.Steps)
+}
+
+// WriteSteps writes the requests, success and latencies of every step
+func WriteSteps(w io.Writer, steps []StepMetrics) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	d := request.FormatDuration
+
+	fmt.Fprintln(tw, "Step\tRequests\tSuccess\tMin\tMean\t50\t90\t95\t99\tMax")
+	for _, s := range steps {
+		l := s.Latencies
+		fmt.Fprintf(tw, "%s\t%d\t%.2f%%\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Name, s.Requests, s.Success*100, d(l.Min), d(l.Mean), d(l.P50), d(l.P90), d(l.P95), d(l.P99), d(l.Max))
+	}
+	return tw.Flush()
+}
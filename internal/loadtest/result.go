@@ -0,0 +1,36 @@
+package loadtest
+
+import "time"
+
+// Result is the outcome of a single request of an attack
+type Result struct {
+	Seq       uint64        `json:"seq"` // Order in which the hits were scheduled
+	Target    string        `json:"target"`
+	Timestamp time.Time     `json:"timestamp"` // When the request was sent
+	Intended  time.Time     `json:"intended"`  // When the request was due by the schedule of the pacer
+	Latency   time.Duration `json:"latency"`   // Service time, from sending the request to the end of the response
+	Code      uint16        `json:"code"`      // Zero if no response was received
+	BytesIn   uint64        `json:"bytesIn"`
+	BytesOut  uint64        `json:"bytesOut"`
+	Error     string        `json:"error,omitempty"` // Transport errors and status codes outside 2xx and 3xx
+}
+
+// Success reports whether the request got a 2xx or 3xx response
+func (r *Result) Success() bool {
+	return r.Code >= 200 && r.Code < 400 && r.Error == ""
+}
+
+// Delay returns how late the request was sent after its intended time
+func (r *Result) Delay() time.Duration {
+	if r.Intended.IsZero() || r.Timestamp.Before(r.Intended) {
+		return 0
+	}
+	return r.Timestamp.Sub(r.Intended)
+}
+
+// ResponseTime returns the latency from the intended send time to the end
+// of the response. Unlike the service time it includes the time the request
+// waited for a worker, which a stalling server causes.
+func (r *Result) ResponseTime() time.Duration {
+	return r.Delay() + r.Latency
+}
@@ -0,0 +1,369 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bata94/reqlab/internal/filter"
+	"github.com/bata94/reqlab/pkgs/apiview"
+)
+
+// Scenario is a closed model load test like k6 runs it: virtual users (VUs)
+// run its steps one after the other in a loop, each step waits for the
+// response of the one before. The number of VUs follows the stages.
+type Scenario struct {
+	Name       string            `yaml:"name"`
+	Spec       string            `yaml:"spec"`       // OpenAPI or Swagger spec for steps with an operation, relative to the scenario
+	Server     string            `yaml:"server"`     // Replaces the servers of the spec, relative step URLs are resolved against it
+	Headers    map[string]string `yaml:"headers"`    // Sent with every step
+	Vars       map[string]string `yaml:"vars"`       // Variables every VU starts an iteration with
+	Think      time.Duration     `yaml:"think"`      // Pause after every step without its own
+	Iterations uint64            `yaml:"iterations"` // Per VU, zero loops until the stages are over
+	VUs        int               `yaml:"vus"`        // Shorthand for a single stage without ramp
+	Duration   time.Duration     `yaml:"duration"`   // Of the shorthand stage
+	Stages     []Stage           `yaml:"stages"`
+	Steps      []ScenarioStep    `yaml:"steps"`
+}
+
+// Stage ramps the VUs linearly from the ones of the stage before, or none,
+// to VUs over Duration. Without a duration they are started at once.
+type Stage struct {
+	Duration time.Duration `yaml:"duration"`
+	VUs      int           `yaml:"vus"`
+}
+
+// ScenarioStep is a request of a scenario. It calls an operation of the spec
+// or a method and URL, the URL, params, headers and body may use variables
+// like {{token}}.
+type ScenarioStep struct {
+	Name      string            `yaml:"name"` // Defaults to the operation ID or method and URL
+	Operation string            `yaml:"operation"`
+	Method    string            `yaml:"method"`
+	URL       string            `yaml:"url"`
+	Params    map[string]string `yaml:"params"` // Path and query params
+	Headers   map[string]string `yaml:"headers"`
+	Body      string            `yaml:"body"`
+	Extract   map[string]string `yaml:"extract"` // Variables set from jq expressions on the JSON response
+	Think     *time.Duration    `yaml:"think"`   // Overrides the think time of the scenario
+
+	endpoint apiview.Endpoint
+	request  apiview.Request
+	extract  []extraction
+	think    time.Duration
+}
+
+type extraction struct {
+	name   string
+	expr   string
+	filter *filter.Filter
+}
+
+// LoadScenario reads a scenario from a YAML file and resolves its steps
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &Scenario{}
+	d := yaml.NewDecoder(bytes.NewReader(data))
+	d.KnownFields(true)
+	if err := d.Decode(sc); err != nil {
+		return nil, fmt.Errorf("reading scenario %s: %w", path, err)
+	}
+	if err := sc.resolve(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", path, err)
+	}
+	return sc, nil
+}
+
+// resolve validates the scenario and builds the requests of its steps, the
+// spec is relative to dir
+func (sc *Scenario) resolve(dir string) error {
+	if len(sc.Steps) == 0 {
+		return errors.New("no steps")
+	}
+	if len(sc.Stages) > 0 && (sc.VUs != 0 || sc.Duration != 0) {
+		return errors.New("either give stages or vus and duration, not both")
+	}
+	if len(sc.Stages) == 0 {
+		sc.Stages = []Stage{{VUs: max(sc.VUs, 1)}, {Duration: sc.Duration, VUs: max(sc.VUs, 1)}}
+	}
+	for _, st := range sc.Stages {
+		if st.VUs < 0 || st.Duration < 0 {
+			return fmt.Errorf("stage %s with %d VUs is negative", st.Duration, st.VUs)
+		}
+	}
+	if sc.duration() == 0 && sc.Iterations == 0 {
+		return errors.New("give a duration, stages or iterations, it would run forever")
+	}
+
+	var endpoints []apiview.Endpoint
+	if sc.Spec != "" {
+		spec := sc.Spec
+		if !filepath.IsAbs(spec) && !strings.Contains(spec, "://") {
+			spec = filepath.Join(dir, spec)
+		}
+		doc, err := apiview.LoadSpec(spec)
+		if err != nil {
+			return err
+		}
+		if endpoints, err = apiview.FromOpenAPI(doc); err != nil {
+			return err
+		}
+	}
+
+	names := map[string]bool{}
+	defined := map[string]bool{"vu": true, "iteration": true}
+	for k := range sc.Vars {
+		defined[k] = true
+	}
+	for i := range sc.Steps {
+		st := &sc.Steps[i]
+		if err := st.resolve(sc, endpoints); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		if names[st.Name] {
+			return fmt.Errorf("step %d: there is another step %q, give it a name", i+1, st.Name)
+		}
+		names[st.Name] = true
+
+		// Variables are only set by the steps before
+		for _, v := range st.variables() {
+			if !defined[v] {
+				return fmt.Errorf("step %d: variable %s is neither in vars nor extracted by a step before", i+1, v)
+			}
+		}
+		for k := range st.Extract {
+			defined[k] = true
+		}
+	}
+	return nil
+}
+
+// resolve builds the endpoint and request template of the step
+func (st *ScenarioStep) resolve(sc *Scenario, endpoints []apiview.Endpoint) error {
+	switch {
+	case st.Operation != "" && st.URL != "":
+		return errors.New("either give an operation or a URL, not both")
+	case st.Operation != "":
+		if sc.Spec == "" {
+			return fmt.Errorf("operation %q needs a spec", st.Operation)
+		}
+		e, ok := apiview.FindEndpoint(endpoints, st.Operation)
+		if !ok {
+			return fmt.Errorf("no operation %q in the spec", st.Operation)
+		}
+		if sc.Server != "" {
+			e.Servers = []string{sc.Server}
+		}
+		if err := e.CheckServer(); err != nil {
+			return fmt.Errorf("%w, give the server of the scenario", err)
+		}
+		st.endpoint = fillExamples(e)
+	case st.URL != "":
+		e, err := scenarioEndpoint(st.Method, st.URL, sc.Server)
+		if err != nil {
+			return err
+		}
+		st.endpoint = e
+	default:
+		return errors.New("give an operation or a URL")
+	}
+
+	if st.Name == "" {
+		st.Name = st.Operation
+		if st.Name == "" {
+			st.Name = strings.ToUpper(st.endpoint.Method.String()) + " " + st.URL
+		}
+	}
+
+	req := apiview.NewRequest(st.endpoint)
+	for _, k := range sortedKeys(st.Params) {
+		req.SetParam(k, st.Params[k])
+	}
+	// Headers of the step replace those of the scenario, which replace
+	// those of the spec
+	for _, headers := range []map[string]string{sc.Headers, st.Headers} {
+		for _, k := range sortedKeys(headers) {
+			req.SetHeader(k, headers[k])
+		}
+	}
+	if st.Body != "" {
+		req.Body = st.Body
+		if req.ContentType == "" && json.Valid([]byte(st.Body)) {
+			req.ContentType = "application/json"
+		}
+	}
+	st.request = req
+
+	for _, name := range sortedKeys(st.Extract) {
+		f, err := filter.Compile(st.Extract[name])
+		if err != nil {
+			return fmt.Errorf("extract %s: %w", name, err)
+		}
+		st.extract = append(st.extract, extraction{name: name, expr: st.Extract[name], filter: f})
+	}
+
+	st.think = sc.Think
+	if st.Think != nil {
+		st.think = *st.Think
+	}
+	return nil
+}
+
+// scenarioEndpoint creates the endpoint of a step with a URL, relative URLs
+// are resolved against server. The path is kept as given so variables in it
+// aren't escaped.
+func scenarioEndpoint(method, rawURL, server string) (apiview.Endpoint, error) {
+	m := apiview.GET
+	if method != "" {
+		var err error
+		if m, err = apiview.ParseHTTPMethod(method); err != nil {
+			return apiview.Endpoint{}, err
+		}
+	}
+
+	e := apiview.Endpoint{Method: m, Path: rawURL}
+	if u, err := url.Parse(rawURL); err == nil && u.Scheme != "" && u.Host != "" {
+		origin := u.Scheme + "://" + u.Host
+		e.Servers = []string{origin}
+		e.Path = strings.TrimPrefix(rawURL, origin)
+	} else if server != "" {
+		e.Servers = []string{server}
+	} else {
+		return e, fmt.Errorf("URL %q is relative but the scenario has no server", rawURL)
+	}
+	if !strings.HasPrefix(e.Path, "/") {
+		e.Path = "/" + e.Path
+	}
+	return e, nil
+}
+
+// duration returns the total duration of the stages
+func (sc *Scenario) duration() time.Duration {
+	var d time.Duration
+	for _, st := range sc.Stages {
+		d += st.Duration
+	}
+	return d
+}
+
+// MaxVUs returns the most VUs any stage runs
+func (sc *Scenario) MaxVUs() int {
+	n := 0
+	for _, st := range sc.Stages {
+		n = max(n, st.VUs)
+	}
+	return n
+}
+
+// vusAt returns the number of VUs the stages call for at elapsed, over
+// reports that the stages are over
+func (sc *Scenario) vusAt(elapsed time.Duration) (vus int, over bool) {
+	prev := 0
+	for _, st := range sc.Stages {
+		if elapsed < st.Duration {
+			ramp := float64(st.VUs-prev) * float64(elapsed) / float64(st.Duration)
+			return prev + int(ramp), false
+		}
+		elapsed -= st.Duration
+		prev = st.VUs
+	}
+	return prev, true
+}
+
+// variableRe matches variables like {{token}} in steps
+var variableRe = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+// variables returns the names of the variables the request of the step uses
+func (st *ScenarioStep) variables() []string {
+	texts := []string{st.request.URL, st.request.Body}
+	for _, p := range st.request.Params {
+		texts = append(texts, p.Value)
+	}
+	for _, h := range st.request.Headers {
+		texts = append(texts, h.Value)
+	}
+
+	var names []string
+	for _, t := range texts {
+		for _, m := range variableRe.FindAllStringSubmatch(t, -1) {
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// render fills in the variables of the request of the step
+func (st *ScenarioStep) render(vars map[string]string) (apiview.Request, error) {
+	var missing []string
+	fill := func(s string) string {
+		return variableRe.ReplaceAllStringFunc(s, func(m string) string {
+			name := variableRe.FindStringSubmatch(m)[1]
+			v, ok := vars[name]
+			if !ok {
+				missing = append(missing, name)
+			}
+			return v
+		})
+	}
+
+	req := st.request
+	req.URL = fill(req.URL)
+	req.Body = fill(req.Body)
+	req.Params = append([]apiview.Param(nil), req.Params...)
+	for i := range req.Params {
+		req.Params[i].Value = fill(req.Params[i].Value)
+	}
+	req.Headers = append([]apiview.Header(nil), req.Headers...)
+	for i := range req.Headers {
+		req.Headers[i].Value = fill(req.Headers[i].Value)
+	}
+
+	if len(missing) > 0 {
+		return req, fmt.Errorf("variable %s is not set", strings.Join(missing, ", "))
+	}
+	return req, nil
+}
+
+// extractVars sets the variables of the step from the response body, the
+// first result of every expression is used
+func (st *ScenarioStep) extractVars(ctx context.Context, body []byte, vars map[string]string) error {
+	for _, x := range st.extract {
+		results, err := x.filter.Run(ctx, body)
+		if err != nil {
+			return fmt.Errorf("extract %s: %w", x.name, err)
+		}
+		if len(results) == 0 || results[0] == nil {
+			return fmt.Errorf("extract %s: %s has no result", x.name, x.expr)
+		}
+		v, err := filter.Format(results[:1], filter.Options{Raw: true, Compact: true})
+		if err != nil {
+			return fmt.Errorf("extract %s: %w", x.name, err)
+		}
+		vars[x.name] = v
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,164 @@
+package loadtest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bata94/reqlab/pkgs/apiview"
+)
+
+func TestVUsAt(t *testing.T) {
+	sc := &Scenario{Stages: []Stage{
+		{Duration: 10 * time.Second, VUs: 10},
+		{Duration: 20 * time.Second, VUs: 10},
+		{VUs: 20}, // At once
+		{Duration: 10 * time.Second, VUs: 0},
+	}}
+
+	tests := []struct {
+		elapsed time.Duration
+		vus     int
+		over    bool
+	}{
+		{0, 0, false},
+		{time.Second, 1, false},
+		{5 * time.Second, 5, false},
+		{10 * time.Second, 10, false},
+		{29 * time.Second, 10, false},
+		{30 * time.Second, 20, false},
+		{35 * time.Second, 10, false},
+		{39 * time.Second, 2, false},
+		{40 * time.Second, 0, true},
+		{time.Hour, 0, true},
+	}
+	for _, tt := range tests {
+		vus, over := sc.vusAt(tt.elapsed)
+		if vus != tt.vus || over != tt.over {
+			t.Errorf("vusAt(%s) = %d, %v, want %d, %v", tt.elapsed, vus, over, tt.vus, tt.over)
+		}
+	}
+
+	// The shorthand of vus and duration starts them at once
+	sc = &Scenario{VUs: 5, Duration: time.Minute, Steps: []ScenarioStep{{URL: "http://localhost/"}}}
+	if err := sc.resolve(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	for _, elapsed := range []time.Duration{0, 30 * time.Second} {
+		if vus, over := sc.vusAt(elapsed); vus != 5 || over {
+			t.Errorf("vusAt(%s) = %d, %v, want 5, false", elapsed, vus, over)
+		}
+	}
+	if _, over := sc.vusAt(time.Minute); !over {
+		t.Error("not over after the duration")
+	}
+}
+
+// resolvedScenario resolves sc and fails the test on errors
+func resolvedScenario(t *testing.T, sc *Scenario) *Scenario {
+	t.Helper()
+	if err := sc.resolve(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	return sc
+}
+
+func TestRender(t *testing.T) {
+	sc := resolvedScenario(t, &Scenario{
+		Server:     "http://localhost:8080",
+		Headers:    map[string]string{"Authorization": "Bearer {{token}}"},
+		Vars:       map[string]string{"token": "", "user": ""},
+		Iterations: 1,
+		Steps: []ScenarioStep{{
+			Method:  "PUT",
+			URL:     "/users/{{user}}",
+			Params:  map[string]string{"vu": "{{ vu }}", "page": "1"},
+			Headers: map[string]string{"X-Iteration": "{{iteration}}"},
+			Body:    `{"name": "{{user}}"}`,
+		}},
+	})
+	st := &sc.Steps[0]
+
+	req, err := st.render(map[string]string{"token": "secret", "user": "jane", "vu": "3", "iteration": "7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "http://localhost:8080/users/jane"; req.URL != want {
+		t.Errorf("URL = %q, want %q", req.URL, want)
+	}
+	if want := `{"name": "jane"}`; req.Body != want {
+		t.Errorf("body = %q, want %q", req.Body, want)
+	}
+	wantParams := []apiview.Param{{Key: "page", Value: "1", Enabled: true}, {Key: "vu", Value: "3", Enabled: true}}
+	if !reflect.DeepEqual(req.Params, wantParams) {
+		t.Errorf("params = %+v, want %+v", req.Params, wantParams)
+	}
+	wantHeaders := []apiview.Header{{Key: "Authorization", Value: "Bearer secret", Enabled: true}, {Key: "X-Iteration", Value: "7", Enabled: true}}
+	if !reflect.DeepEqual(req.Headers, wantHeaders) {
+		t.Errorf("headers = %+v, want %+v", req.Headers, wantHeaders)
+	}
+
+	// The template is left as it was for the next iteration
+	if st.request.URL != "http://localhost:8080/users/{{user}}" || st.request.Headers[0].Value != "Bearer {{token}}" || st.request.Params[1].Value != "{{ vu }}" {
+		t.Errorf("render changed the request of the step: %+v", st.request)
+	}
+
+	if _, err := st.render(map[string]string{"token": "secret"}); err == nil {
+		t.Error("no error for unset variables")
+	}
+}
+
+func TestExtractVars(t *testing.T) {
+	sc := resolvedScenario(t, &Scenario{
+		Iterations: 1,
+		Steps: []ScenarioStep{{
+			URL: "http://localhost/login",
+			Extract: map[string]string{
+				"token": ".token",
+				"id":    ".user.id",
+				"first": ".roles[]",
+				"user":  ".user",
+			},
+		}},
+	})
+	st := &sc.Steps[0]
+
+	vars := map[string]string{"token": "old", "vu": "1"}
+	body := []byte(`{"token": "abc", "user": {"id": 42, "name": "jane"}, "roles": ["admin", "dev"]}`)
+	if err := st.extractVars(context.Background(), body, vars); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"token": "abc",
+		"id":    "42",
+		"first": "admin",
+		"user":  `{"id":42,"name":"jane"}`,
+		"vu":    "1",
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("vars = %v, want %v", vars, want)
+	}
+
+	for name, body := range map[string]string{
+		"missing field": `{"user": {"id": 42}, "roles": []}`,
+		"not JSON":      `<html></html>`,
+	} {
+		if err := st.extractVars(context.Background(), []byte(body), map[string]string{}); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestResolveUndefinedVariable(t *testing.T) {
+	sc := &Scenario{
+		Iterations: 1,
+		Steps: []ScenarioStep{
+			{Name: "first", URL: "http://localhost/users/{{id}}"},
+			{Name: "second", URL: "http://localhost/login", Extract: map[string]string{"id": ".id"}},
+		},
+	}
+	if err := sc.resolve(t.TempDir()); err == nil {
+		t.Error("no error for a variable only extracted by a later step")
+	}
+}
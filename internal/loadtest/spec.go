@@ -0,0 +1,159 @@
+package loadtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bata94/reqlab/pkgs/apiview"
+)
+
+// SpecTargets creates a target for every endpoint of the given operation
+// IDs, all endpoints if there are none. Parameters and bodies without an
+// example are generated from their schema. A server replaces the servers
+// of the spec, without one relative servers are an apiview.ErrRelativeServer.
+func SpecTargets(endpoints []apiview.Endpoint, operations []string, server string) ([]Target, error) {
+	var selected []apiview.Endpoint
+	if len(operations) == 0 {
+		selected = endpoints
+	}
+	for _, id := range operations {
+		e, ok := apiview.FindEndpoint(endpoints, id)
+		if !ok {
+			return nil, fmt.Errorf("no operation %q in the spec", id)
+		}
+		selected = append(selected, e)
+	}
+
+	targets := make([]Target, 0, len(selected))
+	for _, e := range selected {
+		if server != "" {
+			e.Servers = []string{server}
+		}
+		if err := e.CheckServer(); err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, NewEndpointTarget(fillExamples(e)))
+	}
+
+	return targets, nil
+}
+
+// fillExamples generates the examples missing for the required parameters
+// and the body of e. The endpoint is shared with the spec, parameters,
+// headers and bodies are copied before filling them in.
+func fillExamples(e apiview.Endpoint) apiview.Endpoint {
+	e.Parameters = append([]apiview.Parameter(nil), e.Parameters...)
+	e.Headers = append([]apiview.Header(nil), e.Headers...)
+	for i, p := range e.Parameters {
+		if p.Example != nil || !p.Required {
+			continue
+		}
+		e.Parameters[i].Example = apiview.GenerateExample(p.Schema)
+
+		// Requests take their headers from the endpoint, not its parameters
+		if p.In != "header" {
+			continue
+		}
+		for j, h := range e.Headers {
+			if h.Key == p.Name {
+				e.Headers[j].Value = apiview.ValueString(e.Parameters[i].Example)
+			}
+		}
+	}
+	if e.RequestBody != nil && len(e.RequestBody.MediaTypes) > 0 {
+		body := *e.RequestBody
+		body.MediaTypes = append([]apiview.MediaType(nil), body.MediaTypes...)
+		if body.MediaTypes[0].Example == nil {
+			body.MediaTypes[0].Example = apiview.GenerateExample(body.MediaTypes[0].Schema)
+		}
+		e.RequestBody = &body
+	}
+	return e
+}
+
+// Groups of operations a mix can weigh by their method
+const (
+	MixReads  = "reads"  // GET, HEAD and OPTIONS
+	MixWrites = "writes" // Every other method
+)
+
+// MixEntry weighs the targets matching Key, a target name, an operation ID,
+// a tag or one of the groups reads and writes
+type MixEntry struct {
+	Key    string
+	Weight float64
+}
+
+// ParseMixEntry parses an entry like "getUser=3" or "reads=70%"
+func ParseMixEntry(s string) (MixEntry, error) {
+	key, weight, ok := strings.Cut(strings.TrimSpace(s), "=")
+	if !ok || key == "" {
+		return MixEntry{}, fmt.Errorf("invalid mix %q, expected key=weight like reads=70%%", s)
+	}
+
+	w, err := strconv.ParseFloat(strings.TrimSuffix(weight, "%"), 64)
+	if err != nil || w < 0 {
+		return MixEntry{}, fmt.Errorf("invalid mix %q: weight must be a positive number", s)
+	}
+	return MixEntry{Key: key, Weight: w}, nil
+}
+
+// matches reports whether the entry applies to t, by its name or endpoint
+func (m MixEntry) matches(t Target) bool {
+	return matchKey(t, m.Key)
+}
+
+// matchKey reports whether key is the name of t or the operation ID, a tag
+// or the group reads or writes of its endpoint
+func matchKey(t Target, key string) bool {
+	e := t.Endpoint
+	if key == t.Name {
+		return true
+	}
+	if e == nil {
+		return false
+	}
+
+	switch key {
+	case e.OperationID:
+		return true
+	case MixReads:
+		return isRead(e.Method)
+	case MixWrites:
+		return !isRead(e.Method)
+	}
+	for _, tag := range e.Tags {
+		if tag == key {
+			return true
+		}
+	}
+	return false
+}
+
+func isRead(m apiview.HTTPMethod) bool {
+	return m == apiview.GET || m == apiview.HEAD || m == apiview.OPTIONS
+}
+
+// MixWeights computes the weight of every target, the weight of an entry is
+// split evenly among the targets it matches. Targets no entry matches get
+// no weight, entries that match no target are an error.
+func MixWeights(targets []Target, mix []MixEntry) ([]float64, error) {
+	weights := make([]float64, len(targets))
+	for _, m := range mix {
+		var matched []int
+		for i, t := range targets {
+			if m.matches(t) {
+				matched = append(matched, i)
+			}
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("mix %q matches no target", m.Key)
+		}
+		for _, i := range matched {
+			weights[i] += m.Weight / float64(len(matched))
+		}
+	}
+	return weights, nil
+}
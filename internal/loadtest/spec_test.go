@@ -0,0 +1,177 @@
+package loadtest
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/bata94/reqlab/pkgs/apiview"
+	"github.com/bata94/reqlab/pkgs/apiview/openapi"
+)
+
+// specEndpoints are two reads and a write of a users API
+func specEndpoints() []apiview.Endpoint {
+	minimum, multipleOf := 1.0, 5.0
+	return []apiview.Endpoint{
+		{
+			OperationID: "listUsers", Method: apiview.GET, Path: "/users", Tags: []string{"users"},
+			Servers: []string{"https://api.example.com"},
+			Parameters: []apiview.Parameter{
+				{Name: "page", In: "query", Schema: &openapi.Schema{Type: openapi.SchemaType{"integer"}}},
+			},
+		},
+		{
+			OperationID: "getUser", Method: apiview.GET, Path: "/users/{id}", Tags: []string{"users"},
+			Servers: []string{"https://api.example.com"},
+			Parameters: []apiview.Parameter{
+				{Name: "id", In: "path", Required: true, Schema: &openapi.Schema{Type: openapi.SchemaType{"integer"}, Minimum: &minimum, MultipleOf: &multipleOf}},
+				{Name: "X-Tenant", In: "header", Required: true, Schema: &openapi.Schema{Type: openapi.SchemaType{"string"}, Format: "uuid"}},
+			},
+			Headers: []apiview.Header{{Key: "X-Tenant", Enabled: true}},
+		},
+		{
+			OperationID: "createUser", Method: apiview.POST, Path: "/users", Tags: []string{"users", "admin"},
+			Servers: []string{"https://api.example.com"},
+			RequestBody: &apiview.RequestBody{MediaTypes: []apiview.MediaType{{
+				ContentType: "application/json",
+				Schema: &openapi.Schema{
+					Type:       openapi.SchemaType{"object"},
+					Required:   []string{"name"},
+					Properties: map[string]*openapi.Schema{"name": {Type: openapi.SchemaType{"string"}}},
+				},
+			}}},
+		},
+	}
+}
+
+func TestSpecTargets(t *testing.T) {
+	endpoints := specEndpoints()
+
+	targets, err := SpecTargets(endpoints, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tgt := range targets {
+		names = append(names, tgt.Name)
+	}
+	if want := []string{"listUsers", "getUser", "createUser"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("targets %v, want %v", names, want)
+	}
+
+	targets, err = SpecTargets(endpoints, []string{"getUser", "createUser"}, "http://localhost:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	get, create := targets[0].Request, targets[1].Request
+
+	u, err := get.BuildURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The required path param is generated as a multiple of 5, the optional
+	// query param is left out
+	if want := "http://localhost:8080/users/5"; u != want {
+		t.Errorf("URL %s, want %s", u, want)
+	}
+	wantHeaders := []apiview.Header{{Key: "X-Tenant", Value: "3fa85f64-5717-4562-b3fc-2c963f66afa6", Enabled: true}}
+	if !reflect.DeepEqual(get.Headers, wantHeaders) {
+		t.Errorf("headers %+v, want %+v", get.Headers, wantHeaders)
+	}
+	if want := "{\n  \"name\": \"string\"\n}"; create.Body != want {
+		t.Errorf("body %q, want %q", create.Body, want)
+	}
+
+	// The endpoints of the spec are left as they were
+	if !reflect.DeepEqual(endpoints, specEndpoints()) {
+		t.Error("SpecTargets changed the endpoints of the spec")
+	}
+
+	if _, err := SpecTargets(endpoints, []string{"deleteUser"}, ""); err == nil {
+		t.Error("no error for an unknown operation")
+	}
+}
+
+func TestParseMixEntry(t *testing.T) {
+	tests := map[string]MixEntry{
+		"reads=70%":  {Key: "reads", Weight: 70},
+		"getUser=3":  {Key: "getUser", Weight: 3},
+		" admin=0.5": {Key: "admin", Weight: 0.5},
+	}
+	for input, want := range tests {
+		got, err := ParseMixEntry(input)
+		if err != nil {
+			t.Errorf("ParseMixEntry(%q): %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseMixEntry(%q) = %+v, want %+v", input, got, want)
+		}
+	}
+
+	for _, input := range []string{"reads", "=70", "reads=many", "reads=-1"} {
+		if _, err := ParseMixEntry(input); err == nil {
+			t.Errorf("ParseMixEntry(%q) returned no error", input)
+		}
+	}
+}
+
+func TestMixWeights(t *testing.T) {
+	targets, err := SpecTargets(specEndpoints(), nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		mix  []MixEntry
+		want []float64
+	}{
+		{"reads and writes", []MixEntry{{"reads", 70}, {"writes", 30}}, []float64{35, 35, 30}},
+		{"operations", []MixEntry{{"getUser", 3}, {"createUser", 1}}, []float64{0, 3, 1}},
+		{"tag and operation add up", []MixEntry{{"users", 3}, {"getUser", 1}}, []float64{1, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MixWeights(targets, tt.mix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("weights %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := MixWeights(targets, []MixEntry{{"orders", 1}}); err == nil {
+		t.Error("no error for a key matching no target")
+	}
+}
+
+func TestWeightedTargeterHitsReadsAndWrites(t *testing.T) {
+	targets, err := SpecTargets(specEndpoints(), nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	weights, err := MixWeights(targets, []MixEntry{{"reads", 70}, {"writes", 30}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next := NewWeightedTargeter(targets, weights)
+	const n = 100000
+	var writes int
+	for i := 0; i < n; i++ {
+		tgt, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isRead(tgt.Endpoint.Method) {
+			writes++
+		}
+	}
+	// The standard deviation is about 0.15%
+	if share := float64(writes) / n; math.Abs(share-0.3) > 0.01 {
+		t.Errorf("%.1f%% writes, want 30%%", share*100)
+	}
+}
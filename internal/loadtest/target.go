@@ -0,0 +1,88 @@
+package loadtest
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/bata94/reqlab/pkgs/apiview"
+)
+
+// Target is a request the attacker sends, Name identifies it in the results
+type Target struct {
+	Name     string
+	Endpoint *apiview.Endpoint // The target was built from, nil for plain requests
+	Request  apiview.Request
+}
+
+// NewTarget creates a target named after the method and URL of req
+func NewTarget(req apiview.Request) Target {
+	return Target{
+		Name:    strings.ToUpper(req.Method.String()) + " " + req.URL,
+		Request: req,
+	}
+}
+
+// NewEndpointTarget creates a target sending the prefilled request of e,
+// named after its operation ID or method and path
+func NewEndpointTarget(e apiview.Endpoint) Target {
+	name := e.OperationID
+	if name == "" {
+		name = strings.ToUpper(e.Method.String()) + " " + e.Path
+	}
+	return Target{
+		Name:     name,
+		Endpoint: &e,
+		Request:  apiview.NewRequest(e),
+	}
+}
+
+// Targeter returns the next target to hit, it is called concurrently by
+// all workers
+type Targeter func() (Target, error)
+
+// ErrNoTargets is returned by targeters without any targets
+var ErrNoTargets = errors.New("no targets to attack")
+
+// NewStaticTargeter hits the targets round robin
+func NewStaticTargeter(targets ...Target) Targeter {
+	var next atomic.Uint64
+	return func() (Target, error) {
+		if len(targets) == 0 {
+			return Target{}, ErrNoTargets
+		}
+		return targets[(next.Add(1)-1)%uint64(len(targets))], nil
+	}
+}
+
+// NewRandomTargeter hits targets picked at random
+func NewRandomTargeter(targets ...Target) Targeter {
+	return func() (Target, error) {
+		if len(targets) == 0 {
+			return Target{}, ErrNoTargets
+		}
+		return targets[rand.IntN(len(targets))], nil
+	}
+}
+
+// NewWeightedTargeter hits targets picked at random in proportion to their
+// weights, targets without weight are never hit
+func NewWeightedTargeter(targets []Target, weights []float64) Targeter {
+	cumulative := make([]float64, len(weights))
+	var total float64
+	for i, w := range weights {
+		total += w
+		cumulative[i] = total
+	}
+
+	return func() (Target, error) {
+		if len(targets) == 0 || total == 0 {
+			return Target{}, ErrNoTargets
+		}
+		x := rand.Float64() * total
+		i := sort.Search(len(cumulative), func(i int) bool { return cumulative[i] > x })
+		return targets[min(i, len(targets)-1)], nil
+	}
+}
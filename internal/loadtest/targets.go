@@ -0,0 +1,233 @@
+package loadtest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bata94/reqlab/pkgs/apiview"
+)
+
+// Formats of targets files
+const (
+	TargetsHTTP = "http"
+	TargetsJSON = "json"
+)
+
+// ReadTargets parses targets in the given format, files of bodies in the
+// HTTP format are relative to dir.
+//
+// The HTTP format is the one of Vegeta, lines starting with # are comments:
+//
+//	POST https://example.com/users
+//	Content-Type: application/json
+//	@body.json
+//
+//	GET https://example.com/users/1
+//	GET https://example.com/users/2
+//
+// The first line of a target is the method and URL, followed by headers and
+// optionally a line with @ and the file to read the body from. A target ends
+// at an empty line or the method and URL of the next one.
+//
+// The JSON format has one target per line, the body is base64 encoded and
+// the name is optional:
+//
+//	{"name": "createUser", "method": "POST", "url": "https://example.com/users", "header": {"Content-Type": ["application/json"]}, "body": "e30="}
+func ReadTargets(r io.Reader, format, dir string) ([]Target, error) {
+	switch format {
+	case TargetsHTTP:
+		return readHTTPTargets(r, dir)
+	case TargetsJSON:
+		return readJSONTargets(r)
+	}
+	return nil, fmt.Errorf("unknown targets format %q, use %s or %s", format, TargetsHTTP, TargetsJSON)
+}
+
+// ReadTargetsFile reads the targets of a file, "-" reads stdin
+func ReadTargetsFile(path, format string) ([]Target, error) {
+	if path == "-" {
+		return ReadTargets(os.Stdin, format, ".")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	targets, err := ReadTargets(f, format, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return targets, nil
+}
+
+// jsonTarget is a line of the JSON targets format
+type jsonTarget struct {
+	Name   string      `json:"name,omitempty"`
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+func readJSONTargets(r io.Reader) ([]Target, error) {
+	var targets []Target
+
+	d := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var jt jsonTarget
+		if err := d.Decode(&jt); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("target %d: %w", line, err)
+		}
+
+		tgt, err := newFileTarget(jt.Method, jt.URL, jt.Header, string(jt.Body))
+		if err != nil {
+			return nil, fmt.Errorf("target %d: %w", line, err)
+		}
+		if jt.Name != "" {
+			tgt.Name = jt.Name
+		}
+		targets = append(targets, tgt)
+	}
+
+	return targets, nil
+}
+
+func readHTTPTargets(r io.Reader, dir string) ([]Target, error) {
+	var (
+		targets []Target
+		open    bool // Inside a target
+		header  http.Header
+		body    string
+		method  string
+		rawURL  string
+		start   int
+	)
+
+	flush := func() error {
+		if !open {
+			return nil
+		}
+		tgt, err := newFileTarget(method, rawURL, header, body)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", start, err)
+		}
+		targets = append(targets, tgt)
+		open = false
+		return nil
+	}
+
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if m, u, ok := requestLine(text); ok {
+			// A request line starts the next target even without an empty
+			// line before it
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			method, rawURL = m, u
+			open, header, body, start = true, http.Header{}, "", line
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "#"):
+			continue
+		case text == "":
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		case !open:
+			return nil, fmt.Errorf("line %d: expected method and URL, got %q", line, text)
+		case strings.HasPrefix(text, "@"):
+			path := text[1:]
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			body = string(data)
+		default:
+			key, value, ok := strings.Cut(text, ":")
+			if !ok {
+				return nil, fmt.Errorf("line %d: expected a header or @body, got %q", line, text)
+			}
+			header.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// requestLine splits a line like "GET https://example.com" into its method
+// and URL, ok is false if it doesn't start with a method
+func requestLine(text string) (method, rawURL string, ok bool) {
+	method, rawURL, ok = strings.Cut(text, " ")
+	if !ok {
+		return "", "", false
+	}
+	if _, err := apiview.ParseHTTPMethod(method); err != nil {
+		return "", "", false
+	}
+	return method, strings.TrimSpace(rawURL), true
+}
+
+// newFileTarget turns a target of a file into the endpoint it calls, the
+// server is the origin of rawURL and the path includes the query
+func newFileTarget(method, rawURL string, header http.Header, body string) (Target, error) {
+	m, err := apiview.ParseHTTPMethod(method)
+	if err != nil {
+		return Target{}, err
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Target{}, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return Target{}, fmt.Errorf("URL %q is not absolute", rawURL)
+	}
+
+	e := apiview.Endpoint{
+		Path:    u.RequestURI(),
+		Method:  m,
+		Servers: []string{u.Scheme + "://" + u.Host},
+	}
+
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			e.Headers = append(e.Headers, apiview.Header{Key: k, Value: v, Enabled: true})
+		}
+	}
+
+	tgt := NewEndpointTarget(e)
+	tgt.Name = strings.ToUpper(m.String()) + " " + rawURL
+	tgt.Request.Body = body
+	return tgt, nil
+}
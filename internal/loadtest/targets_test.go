@@ -0,0 +1,164 @@
+package loadtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// targetSummary is what a test checks of a target read from a file
+type targetSummary struct {
+	Name    string
+	URL     string
+	Headers []string
+	Body    string
+}
+
+func summarize(targets []Target) []targetSummary {
+	out := make([]targetSummary, 0, len(targets))
+	for _, t := range targets {
+		s := targetSummary{Name: t.Name, URL: t.Request.URL, Body: t.Request.Body}
+		for _, h := range t.Request.Headers {
+			s.Headers = append(s.Headers, h.Key+": "+h.Value)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestReadHTTPTargets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "user.json"), []byte(`{"name":"jane"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []targetSummary
+	}{
+		{
+			name: "separated by empty lines",
+			input: `# Users
+POST https://example.com/users
+Content-Type: application/json
+X-Tag: a
+X-Tag: b
+@user.json
+
+GET https://example.com/users?page=2
+
+
+DELETE https://example.com/users/1
+`,
+			want: []targetSummary{
+				{Name: "POST https://example.com/users", URL: "https://example.com/users", Headers: []string{"Content-Type: application/json", "X-Tag: a", "X-Tag: b"}, Body: `{"name":"jane"}`},
+				{Name: "GET https://example.com/users?page=2", URL: "https://example.com/users?page=2"},
+				{Name: "DELETE https://example.com/users/1", URL: "https://example.com/users/1"},
+			},
+		},
+		{
+			name: "one per line",
+			input: `GET https://example.com/a
+GET https://example.com/b
+HEAD https://example.com/c`,
+			want: []targetSummary{
+				{Name: "GET https://example.com/a", URL: "https://example.com/a"},
+				{Name: "GET https://example.com/b", URL: "https://example.com/b"},
+				{Name: "HEAD https://example.com/c", URL: "https://example.com/c"},
+			},
+		},
+		{
+			name: "next target right after headers and body",
+			input: `PUT https://example.com/users/1
+Authorization: Bearer token
+@user.json
+GET https://example.com/users/1
+Accept: application/json
+# A comment in between
+GET https://example.com/users
+`,
+			want: []targetSummary{
+				{Name: "PUT https://example.com/users/1", URL: "https://example.com/users/1", Headers: []string{"Authorization: Bearer token"}, Body: `{"name":"jane"}`},
+				{Name: "GET https://example.com/users/1", URL: "https://example.com/users/1", Headers: []string{"Accept: application/json"}},
+				{Name: "GET https://example.com/users", URL: "https://example.com/users"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := ReadTargets(strings.NewReader(tt.input), TargetsHTTP, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := summarize(targets); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadHTTPTargetsHost(t *testing.T) {
+	input := `GET http://127.0.0.1:8080/users
+Host: api.example.com
+Accept: application/json
+`
+	targets, err := ReadTargets(strings.NewReader(input), TargetsHTTP, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := targets[0].Request.HTTPRequest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Host != "api.example.com" {
+		t.Errorf("host %q, want api.example.com", req.Host)
+	}
+	if req.URL.Host != "127.0.0.1:8080" {
+		t.Errorf("dials %q, want 127.0.0.1:8080", req.URL.Host)
+	}
+	if _, ok := req.Header["Host"]; ok {
+		t.Error("Host is left among the headers")
+	}
+	if got := req.Header.Get("Accept"); got != "application/json" {
+		t.Errorf("Accept %q, want application/json", got)
+	}
+}
+
+func TestReadHTTPTargetsErrors(t *testing.T) {
+	tests := map[string]string{
+		"header first":  "Accept: application/json\nGET https://example.com\n",
+		"relative URL":  "GET /users\n",
+		"missing body":  "POST https://example.com\n@missing.json\n",
+		"not a header":  "GET https://example.com\nnonsense\n",
+		"unknown thing": "FETCH https://example.com\n",
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ReadTargets(strings.NewReader(input), TargetsHTTP, t.TempDir()); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+}
+
+func TestReadJSONTargets(t *testing.T) {
+	input := `{"name": "createUser", "method": "POST", "url": "https://example.com/users", "header": {"Content-Type": ["application/json"]}, "body": "e30="}
+{"method": "GET", "url": "https://example.com/users"}
+`
+	targets, err := ReadTargets(strings.NewReader(input), TargetsJSON, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []targetSummary{
+		{Name: "createUser", URL: "https://example.com/users", Headers: []string{"Content-Type: application/json"}, Body: "{}"},
+		{Name: "GET https://example.com/users", URL: "https://example.com/users"},
+	}
+	if got := summarize(targets); !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,335 @@
+package loadtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bata94/reqlab/internal/request"
+)
+
+// Metrics thresholds can be set on
+const (
+	ThresholdMin        = "min"
+	ThresholdMean       = "mean"
+	ThresholdP50        = "p50"
+	ThresholdP90        = "p90"
+	ThresholdP95        = "p95"
+	ThresholdP99        = "p99"
+	ThresholdP999       = "p99.9"
+	ThresholdMax        = "max"
+	ThresholdErrorRate  = "error_rate"
+	ThresholdSuccess    = "success"
+	ThresholdRPS        = "rps"
+	ThresholdThroughput = "throughput"
+	ThresholdRequests   = "requests"
+)
+
+// thresholdQuantiles are the quantiles of the percentile metrics
+var thresholdQuantiles = map[string]float64{
+	ThresholdP50:  0.5,
+	ThresholdP90:  0.9,
+	ThresholdP95:  0.95,
+	ThresholdP99:  0.99,
+	ThresholdP999: 0.999,
+}
+
+// thresholdOps are the comparisons, the longer ones first for parsing
+var thresholdOps = []string{"<=", ">=", "==", "<", ">"}
+
+// ErrThresholdBreached aborts an attack once a threshold can't pass anymore
+var ErrThresholdBreached = errors.New("threshold breached")
+
+// Threshold is a pass/fail criterion on the metrics of an attack like
+// p95<300ms, limited to the targets matching Scope if it is set
+type Threshold struct {
+	Scope  string // Target name, operation ID, tag, reads or writes
+	Metric string
+	Op     string
+	Value  float64 // Nanoseconds for latencies, a ratio for error_rate and success, per second for rps and throughput
+	raw    string
+}
+
+// ParseThreshold parses a threshold like "p95<300ms", "error_rate<1%",
+// "rps>=200" or scoped to targets "getUser:p99<=1s"
+func ParseThreshold(s string) (Threshold, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexAny(s, "<>=")
+	if i <= 0 {
+		return Threshold{}, fmt.Errorf("invalid threshold %q, expected metric, comparison and value like p95<300ms", s)
+	}
+
+	var t Threshold
+	for _, op := range thresholdOps {
+		if strings.HasPrefix(s[i:], op) {
+			t.Op = op
+			break
+		}
+	}
+	if t.Op == "" {
+		return t, fmt.Errorf("invalid threshold %q: use one of %s", s, strings.Join(thresholdOps, " "))
+	}
+
+	t.Metric = strings.TrimSpace(s[:i])
+	if j := strings.LastIndex(t.Metric, ":"); j >= 0 {
+		t.Scope, t.Metric = strings.TrimSpace(t.Metric[:j]), strings.TrimSpace(t.Metric[j+1:])
+	}
+	t.raw = strings.TrimSpace(s[i+len(t.Op):])
+
+	var err error
+	switch {
+	case t.isLatency():
+		var d time.Duration
+		d, err = time.ParseDuration(t.raw)
+		t.Value = float64(d)
+	case t.Metric == ThresholdErrorRate || t.Metric == ThresholdSuccess:
+		if pct, ok := strings.CutSuffix(t.raw, "%"); ok {
+			t.Value, err = strconv.ParseFloat(pct, 64)
+			t.Value /= 100
+		} else {
+			t.Value, err = strconv.ParseFloat(t.raw, 64)
+		}
+		if err == nil && (t.Value < 0 || t.Value > 1) {
+			err = fmt.Errorf("must be between 0%% and 100%%")
+		}
+	case t.Metric == ThresholdRPS || t.Metric == ThresholdThroughput:
+		var r Rate
+		r, err = ParseRate(t.raw)
+		t.Value = r.PerSecond()
+	case t.Metric == ThresholdRequests:
+		var n uint64
+		n, err = strconv.ParseUint(t.raw, 10, 64)
+		t.Value = float64(n)
+	default:
+		return t, fmt.Errorf("invalid threshold %q: unknown metric %q", s, t.Metric)
+	}
+	if err != nil {
+		return t, fmt.Errorf("invalid threshold %q: %w", s, err)
+	}
+	return t, nil
+}
+
+func (t Threshold) String() string {
+	if t.Scope != "" {
+		return t.Scope + ":" + t.Metric + t.Op + t.raw
+	}
+	return t.Metric + t.Op + t.raw
+}
+
+func (t Threshold) isLatency() bool {
+	switch t.Metric {
+	case ThresholdMin, ThresholdMean, ThresholdMax:
+		return true
+	}
+	_, ok := thresholdQuantiles[t.Metric]
+	return ok
+}
+
+// actual returns the value of the metric in m, which has to be closed.
+// Latencies are response times, they include the time a request waited for
+// a worker.
+func (t Threshold) actual(m *Metrics) float64 {
+	switch t.Metric {
+	case ThresholdMin:
+		return float64(m.ResponseTimes.Min)
+	case ThresholdMean:
+		return float64(m.ResponseTimes.Mean)
+	case ThresholdMax:
+		return float64(m.ResponseTimes.Max)
+	case ThresholdErrorRate:
+		return 1 - m.Success
+	case ThresholdSuccess:
+		return m.Success
+	case ThresholdRPS:
+		return m.Rate
+	case ThresholdThroughput:
+		return m.Throughput
+	case ThresholdRequests:
+		return float64(m.Requests)
+	}
+	return float64(m.ResponseHistogram.Quantile(thresholdQuantiles[t.Metric]))
+}
+
+// pass compares v to the value of t
+func (t Threshold) pass(v float64) bool {
+	switch t.Op {
+	case "<":
+		return v < t.Value
+	case "<=":
+		return v <= t.Value
+	case ">":
+		return v > t.Value
+	case ">=":
+		return v >= t.Value
+	}
+	return v == t.Value
+}
+
+// format formats v in the unit of the metric
+func (t Threshold) format(v float64) string {
+	switch {
+	case t.isLatency():
+		return request.FormatDuration(time.Duration(v))
+	case t.Metric == ThresholdErrorRate || t.Metric == ThresholdSuccess:
+		return strconv.FormatFloat(v*100, 'f', 2, 64) + "%"
+	case t.Metric == ThresholdRequests:
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64) + "/s"
+}
+
+// ThresholdResult is the outcome of a threshold
+type ThresholdResult struct {
+	Threshold Threshold
+	Requests  uint64 // Results in the scope of the threshold
+	Actual    float64
+	Pass      bool // False without results
+}
+
+// Thresholds checks thresholds against the results of an attack while they
+// come in
+type Thresholds struct {
+	list   []Threshold
+	scopes map[string]*thresholdScope
+}
+
+// thresholdScope collects the metrics of the targets a scope matches, all
+// targets if it is empty
+type thresholdScope struct {
+	targets map[string]bool
+	metrics Metrics
+}
+
+// NewThresholds creates the checker of list for an attack of targets, a scope
+// matching none of them is an error
+func NewThresholds(list []Threshold, targets []Target) (*Thresholds, error) {
+	t := &Thresholds{list: list, scopes: map[string]*thresholdScope{}}
+	for _, th := range list {
+		if _, ok := t.scopes[th.Scope]; ok {
+			continue
+		}
+
+		s := &thresholdScope{}
+		if th.Scope != "" {
+			s.targets = map[string]bool{}
+			for _, target := range targets {
+				if matchKey(target, th.Scope) {
+					s.targets[target.Name] = true
+				}
+			}
+			if len(s.targets) == 0 {
+				return nil, fmt.Errorf("threshold %q matches no target", th)
+			}
+		}
+		t.scopes[th.Scope] = s
+	}
+	return t, nil
+}
+
+// Add aggregates a result in every scope it falls into
+func (t *Thresholds) Add(r *Result) {
+	for _, s := range t.scopes {
+		if s.targets == nil || s.targets[r.Target] {
+			s.metrics.Add(r)
+		}
+	}
+}
+
+// Check evaluates all thresholds against the results so far
+func (t *Thresholds) Check() []ThresholdResult {
+	results := make([]ThresholdResult, len(t.list))
+	for i, th := range t.list {
+		m := t.scopes[th.Scope].metrics
+		m.Close()
+
+		results[i] = ThresholdResult{Threshold: th, Requests: m.Requests}
+		if m.Requests > 0 {
+			results[i].Actual = th.actual(&m)
+			results[i].Pass = th.pass(results[i].Actual)
+		}
+	}
+	return results
+}
+
+// Breached returns a threshold that fails no matter what results follow, if
+// there is one. limit is the most requests the attack sends, zero if it is
+// not known. Without it only min, max and requests can be breached for good.
+func (t *Thresholds) Breached(limit uint64) (Threshold, bool) {
+	for _, th := range t.list {
+		if t.breached(th, &t.scopes[th.Scope].metrics, limit) {
+			return th, true
+		}
+	}
+	return Threshold{}, false
+}
+
+func (t *Thresholds) breached(th Threshold, m *Metrics, limit uint64) bool {
+	if m.Requests == 0 {
+		return false
+	}
+	below := th.Op == "<" || th.Op == "<="
+	above := th.Op == ">" || th.Op == ">="
+
+	// These only ever grow or shrink
+	switch {
+	case th.Metric == ThresholdMax && below:
+		return !th.pass(float64(m.ResponseHistogram.Max()))
+	case th.Metric == ThresholdMin && above:
+		return !th.pass(float64(m.ResponseHistogram.Min()))
+	case th.Metric == ThresholdRequests && below:
+		return !th.pass(float64(m.Requests))
+	}
+
+	if limit == 0 || m.Requests > limit {
+		return false
+	}
+	n, rest := float64(limit), float64(limit-m.Requests)
+	switch {
+	case th.Metric == ThresholdErrorRate && below:
+		// Even if all remaining requests succeed
+		return !th.pass(float64(m.Requests-m.success) / n)
+	case th.Metric == ThresholdSuccess && above:
+		return !th.pass((float64(m.success) + rest) / n)
+	}
+	if q, ok := thresholdQuantiles[th.Metric]; ok && below {
+		// More latencies above the value than the quantile allows of the
+		// most requests there will be
+		allowed := n - math.Ceil(q*n)
+		return float64(m.ResponseHistogram.above(time.Duration(th.Value))) > allowed
+	}
+	return false
+}
+
+// Failed returns the number of thresholds that failed
+func Failed(results []ThresholdResult) int {
+	n := 0
+	for _, r := range results {
+		if !r.Pass {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteThresholds writes a line per threshold with its actual value and
+// whether it passed
+func WriteThresholds(w io.Writer, results []ThresholdResult) error {
+	fmt.Fprintln(w, "Thresholds:")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, r := range results {
+		status, actual := "PASS", r.Threshold.format(r.Actual)
+		if !r.Pass {
+			status = "FAIL"
+		}
+		if r.Requests == 0 {
+			actual = "no results"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s = %s\n", status, r.Threshold, r.Threshold.Metric, actual)
+	}
+	return tw.Flush()
+}
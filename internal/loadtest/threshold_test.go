@@ -0,0 +1,208 @@
+package loadtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bata94/reqlab/pkgs/apiview"
+)
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Threshold
+	}{
+		{"p95<300ms", Threshold{Metric: ThresholdP95, Op: "<", Value: float64(300 * time.Millisecond)}},
+		{" max <= 1s ", Threshold{Metric: ThresholdMax, Op: "<=", Value: float64(time.Second)}},
+		{"p99.9<2s", Threshold{Metric: ThresholdP999, Op: "<", Value: float64(2 * time.Second)}},
+		{"min>=1ms", Threshold{Metric: ThresholdMin, Op: ">=", Value: float64(time.Millisecond)}},
+		{"error_rate<1%", Threshold{Metric: ThresholdErrorRate, Op: "<", Value: 0.01}},
+		{"success>0.99", Threshold{Metric: ThresholdSuccess, Op: ">", Value: 0.99}},
+		{"rps>=200", Threshold{Metric: ThresholdRPS, Op: ">=", Value: 200}},
+		{"throughput>60/m", Threshold{Metric: ThresholdThroughput, Op: ">", Value: 1}},
+		{"requests==100", Threshold{Metric: ThresholdRequests, Op: "==", Value: 100}},
+		{"getUser:p99<=1s", Threshold{Scope: "getUser", Metric: ThresholdP99, Op: "<=", Value: float64(time.Second)}},
+		// Target names contain colons, the metric follows the last one
+		{"GET http://localhost:8080/users:mean<50ms", Threshold{Scope: "GET http://localhost:8080/users", Metric: ThresholdMean, Op: "<", Value: float64(50 * time.Millisecond)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseThreshold(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Scope != tt.want.Scope || got.Metric != tt.want.Metric || got.Op != tt.want.Op || got.Value != tt.want.Value {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	for _, input := range []string{
+		"",
+		"p95",
+		"<300ms",
+		"p95=>300ms",
+		"p95<fast",
+		"p42<300ms",
+		"error_rate<150%",
+		"success>-1",
+		"rps>many",
+		"requests<-1",
+	} {
+		if _, err := ParseThreshold(input); err == nil {
+			t.Errorf("ParseThreshold(%q) returned no error", input)
+		}
+	}
+}
+
+func TestThresholdString(t *testing.T) {
+	for _, s := range []string{"p95<300ms", "error_rate<1%", "getUser:rps>=200/s"} {
+		th, err := ParseThreshold(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if th.String() != s {
+			t.Errorf("String() = %q, want %q", th.String(), s)
+		}
+	}
+}
+
+// thresholdTargets are a read and a write of the users tag and a plain request
+func thresholdTargets() []Target {
+	return []Target{
+		NewEndpointTarget(apiview.Endpoint{OperationID: "getUser", Method: apiview.GET, Path: "/users/{id}", Tags: []string{"users"}}),
+		NewEndpointTarget(apiview.Endpoint{OperationID: "createUser", Method: apiview.POST, Path: "/users", Tags: []string{"users"}}),
+		NewTarget(apiview.Request{Method: apiview.GET, URL: "http://localhost/health"}),
+	}
+}
+
+// mustThresholds parses list and creates its checker for targets
+func mustThresholds(t *testing.T, targets []Target, list ...string) *Thresholds {
+	t.Helper()
+	parsed := make([]Threshold, 0, len(list))
+	for _, s := range list {
+		th, err := ParseThreshold(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed = append(parsed, th)
+	}
+	th, err := NewThresholds(parsed, targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return th
+}
+
+func TestThresholdScopes(t *testing.T) {
+	targets := thresholdTargets()
+	th := mustThresholds(t, targets,
+		"requests>0",
+		"getUser:requests>0",
+		"users:requests>0",
+		"reads:requests>0",
+		"writes:requests>0",
+		"GET http://localhost/health:requests>0",
+	)
+
+	start := time.Unix(1700000000, 0)
+	for i, target := range []string{"getUser", "getUser", "createUser", "GET http://localhost/health"} {
+		ts := start.Add(time.Duration(i) * time.Millisecond)
+		th.Add(&Result{Target: target, Timestamp: ts, Intended: ts, Latency: time.Millisecond, Code: 200})
+	}
+
+	want := []uint64{4, 2, 3, 2, 1, 1}
+	for i, r := range th.Check() {
+		if r.Requests != want[i] {
+			t.Errorf("%s counted %d requests, want %d", r.Threshold, r.Requests, want[i])
+		}
+		if !r.Pass {
+			t.Errorf("%s failed", r.Threshold)
+		}
+	}
+
+	th2, err := ParseThreshold("orders:p95<1s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewThresholds([]Threshold{th2}, targets); err == nil {
+		t.Error("a scope matching no target returned no error")
+	}
+}
+
+func TestThresholdsWithoutResults(t *testing.T) {
+	th := mustThresholds(t, thresholdTargets(), "p95<1s")
+	r := th.Check()[0]
+	if r.Pass || r.Requests != 0 {
+		t.Errorf("got %+v, want a failure without requests", r)
+	}
+}
+
+func TestThresholdsUseResponseTimes(t *testing.T) {
+	th := mustThresholds(t, thresholdTargets(), "min<100ms", "mean<100ms", "p95<100ms", "max<100ms")
+
+	// Served fast, but sent half a second late
+	start := time.Unix(1700000000, 0)
+	for i := 0; i < 10; i++ {
+		intended := start.Add(time.Duration(i) * time.Millisecond)
+		th.Add(&Result{Target: "getUser", Timestamp: intended.Add(500 * time.Millisecond), Intended: intended, Latency: 10 * time.Millisecond, Code: 200})
+	}
+
+	for _, r := range th.Check() {
+		if r.Pass {
+			t.Errorf("%s passed with %s", r.Threshold, r.Threshold.format(r.Actual))
+		}
+		if r.Actual < float64(500*time.Millisecond) {
+			t.Errorf("%s = %s, the service time instead of the response time", r.Threshold, r.Threshold.format(r.Actual))
+		}
+	}
+	if _, ok := th.Breached(0); !ok {
+		t.Error("max<100ms is not breached by a response time of 510ms")
+	}
+}
+
+func TestThresholdsBreached(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	result := func(latency time.Duration, code uint16) *Result {
+		return &Result{Target: "getUser", Timestamp: start, Intended: start, Latency: latency, Code: code}
+	}
+	fast, slow, failed := result(10*time.Millisecond, 200), result(time.Second, 200), result(10*time.Millisecond, 500)
+
+	tests := []struct {
+		name      string
+		threshold string
+		results   []*Result
+		limit     uint64
+		want      bool
+	}{
+		{"no results", "max<100ms", nil, 100, false},
+		{"max exceeded", "max<100ms", []*Result{fast, slow}, 0, true},
+		{"max met", "max<100ms", []*Result{fast, fast}, 0, false},
+		{"max above can still be met", "max>100ms", []*Result{fast}, 0, false},
+		{"min below", "min>100ms", []*Result{slow, fast}, 0, true},
+		{"min met", "min>1ms", []*Result{fast}, 0, false},
+		{"requests exceeded", "requests<2", []*Result{fast, fast}, 0, true},
+		{"requests below", "requests<3", []*Result{fast, fast}, 0, false},
+		{"error rate without a limit", "error_rate<10%", []*Result{failed, failed}, 0, false},
+		{"error rate exceeded for good", "error_rate<10%", []*Result{failed, failed}, 10, true},
+		{"error rate still possible", "error_rate<=20%", []*Result{failed, failed}, 10, false},
+		{"success unreachable", "success>=90%", []*Result{failed, failed}, 10, true},
+		{"success still possible", "success>=80%", []*Result{failed, failed}, 10, false},
+		{"percentile exceeded for good", "p90<100ms", []*Result{slow, slow}, 10, true},
+		{"percentile still possible", "p90<100ms", []*Result{slow}, 10, false},
+		{"percentile without a limit", "p90<100ms", []*Result{slow, slow}, 0, false},
+		{"more requests than the limit", "error_rate<10%", []*Result{failed, failed}, 1, false},
+		{"mean is never final", "mean<100ms", []*Result{slow, slow}, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := mustThresholds(t, thresholdTargets(), tt.threshold)
+			for _, r := range tt.results {
+				th.Add(r)
+			}
+			if _, got := th.Breached(tt.limit); got != tt.want {
+				t.Errorf("Breached(%d) = %v, want %v", tt.limit, got, tt.want)
+			}
+		})
+	}
+}
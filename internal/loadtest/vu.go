@@ -0,0 +1,199 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ScenarioOptions configure the HTTP client of a scenario run
+type ScenarioOptions struct {
+	Timeout          time.Duration // Per request
+	DisableKeepAlive bool
+	MaxConnections   int // Per host, zero means no limit
+}
+
+// scenarioTick is how often the number of VUs follows the stages
+const scenarioTick = 100 * time.Millisecond
+
+// GracefulStop is how long VUs may finish their iteration once the stages
+// are over, then they are stopped
+const GracefulStop = 30 * time.Second
+
+// ScenarioRunner runs the VUs of a scenario
+type ScenarioRunner struct {
+	sc     *Scenario
+	client *http.Client
+	seq    atomic.Uint64
+	active atomic.Int64
+}
+
+// NewScenarioRunner creates a runner of sc, its HTTP client is set up from
+// opts
+func NewScenarioRunner(sc *Scenario, opts ScenarioOptions) *ScenarioRunner {
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	return &ScenarioRunner{
+		sc:     sc,
+		client: newClient(opts.Timeout, opts.DisableKeepAlive, opts.MaxConnections, sc.MaxVUs()),
+	}
+}
+
+// VUs returns the number of VUs currently running
+func (r *ScenarioRunner) VUs() int {
+	return int(r.active.Load())
+}
+
+// vu is a virtual user, closing stop ends it after its current iteration
+type vu struct {
+	id   int
+	stop chan struct{}
+}
+
+// Run starts the VUs and sends a result for every step they run. Ramping
+// down stops the VUs started last once they finish their iteration. Without
+// durations every VU runs its iterations. The channel is closed once all
+// VUs are done, when ctx is done they stop after their current step.
+func (r *ScenarioRunner) Run(ctx context.Context) <-chan *Result {
+	results := make(chan *Result)
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		defer close(results)
+		defer cancel()
+
+		var (
+			wg      sync.WaitGroup
+			vus     []*vu
+			started int
+			timed   = r.sc.duration() > 0
+			start   = time.Now()
+			ticker  = time.NewTicker(scenarioTick)
+		)
+		defer ticker.Stop()
+
+	stages:
+		for {
+			want, over := r.sc.vusAt(time.Since(start))
+			if over && timed {
+				break
+			}
+
+			for len(vus) < want {
+				started++
+				v := &vu{id: started, stop: make(chan struct{})}
+				vus = append(vus, v)
+				r.active.Add(1)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer r.active.Add(-1)
+					r.runVU(ctx, v, results)
+				}()
+			}
+			for len(vus) > want {
+				close(vus[len(vus)-1].stop)
+				vus = vus[:len(vus)-1]
+			}
+
+			if !timed {
+				break
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				break stages
+			}
+		}
+
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		if !timed {
+			<-done
+			return
+		}
+
+		for _, v := range vus {
+			close(v.stop)
+		}
+		select {
+		case <-done:
+		case <-time.After(GracefulStop):
+			cancel()
+			<-done
+		}
+	}()
+
+	return results
+}
+
+// runVU runs the iterations of a VU until it is stopped
+func (r *ScenarioRunner) runVU(ctx context.Context, v *vu, results chan<- *Result) {
+	for it := uint64(0); r.sc.Iterations == 0 || it < r.sc.Iterations; it++ {
+		vars := maps.Clone(r.sc.Vars)
+		if vars == nil {
+			vars = map[string]string{}
+		}
+		vars["vu"] = strconv.Itoa(v.id)
+		vars["iteration"] = strconv.FormatUint(it, 10)
+
+		for i := range r.sc.Steps {
+			if ctx.Err() != nil {
+				return
+			}
+			st := &r.sc.Steps[i]
+			res := r.step(ctx, st, vars)
+			results <- res
+
+			if st.think > 0 && !sleep(ctx, st.think) {
+				return
+			}
+			// Later steps likely depend on this one, the iteration is
+			// started over
+			if !res.Success() {
+				break
+			}
+		}
+
+		select {
+		case <-v.stop:
+			return
+		case <-ctx.Done():
+			return
+		default:
+		}
+	}
+}
+
+// step sends the request of a step and extracts its variables from the
+// response
+func (r *ScenarioRunner) step(ctx context.Context, st *ScenarioStep, vars map[string]string) *Result {
+	res := &Result{Seq: r.seq.Add(1) - 1, Target: st.Name, Timestamp: time.Now()}
+	res.Intended = res.Timestamp
+
+	req, err := st.render(vars)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	var body bytes.Buffer
+	send(ctx, r.client, req, res, &body)
+	res.Latency = time.Since(res.Timestamp)
+
+	if res.Success() {
+		if err := st.extractVars(ctx, body.Bytes(), vars); err != nil {
+			res.Error = err.Error()
+		}
+	}
+	return res
+}
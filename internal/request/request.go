@@ -0,0 +1,53 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bata94/reqlab/pkgs/apiview"
+)
+
+// Response is the result of an executed request, the body is read completely
+type Response struct {
+	*http.Response
+	Body     []byte
+	Duration time.Duration
+	Timing   Timing
+}
+
+// Execute sends req and reads its response. Cancelling ctx aborts the
+// request, a nil client uses http.DefaultClient.
+func Execute(ctx context.Context, client *http.Client, req apiview.Request) (*Response, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	trace := &tracer{}
+	httpReq, err := req.HTTPRequest(httptrace.WithClientTrace(ctx, trace.clientTrace()))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug("Sending ", httpReq.Method, " request to: ", httpReq.URL)
+	trace.start = time.Now()
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	timing := trace.timing(time.Now())
+	log.Debug("Response status: ", resp.Status, " after ", timing.Total)
+
+	return &Response{Response: resp, Body: body, Duration: timing.Total, Timing: timing}, nil
+}
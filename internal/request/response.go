@@ -0,0 +1,66 @@
+package request
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// ContentType returns the media type of the response without parameters.
+// If the server did not send one it is sniffed from the body.
+func (r *Response) ContentType() string {
+	value := r.Header.Get("Content-Type")
+	if value == "" {
+		value = http.DetectContentType(r.Body)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return value
+	}
+	return mediaType
+}
+
+// Raw returns the response in its HTTP/1.1 wire form. The body is the one
+// that was read, i.e. already decompressed and without chunked encoding.
+func (r *Response) Raw() []byte {
+	var b bytes.Buffer
+	r.writeHead(&b)
+	b.Write(r.Body)
+	return b.Bytes()
+}
+
+// Head returns the status line and headers in wire form, ending with the
+// empty line before the body
+func (r *Response) Head() []byte {
+	var b bytes.Buffer
+	r.writeHead(&b)
+	return b.Bytes()
+}
+
+// HeaderSize is the size of the status line and headers in wire form
+func (r *Response) HeaderSize() int {
+	return len(r.Head())
+}
+
+func (r *Response) writeHead(b *bytes.Buffer) {
+	fmt.Fprintf(b, "%s %s\r\n", r.Proto, r.Status)
+	r.Header.Write(b)
+	b.WriteString("\r\n")
+}
+
+// FormatSize formats a number of bytes with a binary unit
+func FormatSize(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := unit, 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,175 @@
+package request
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timing breaks down where the time of a request went, like curl -w or
+// httpstat do. Phases of a reused connection are zero.
+type Timing struct {
+	DNSLookup        time.Duration
+	TCPConnect       time.Duration
+	TLSHandshake     time.Duration
+	ServerProcessing time.Duration // Request written until the first response byte
+	ContentTransfer  time.Duration // First until the last response byte
+	FirstByte        time.Duration // Start until the first response byte
+	Total            time.Duration
+	ConnReused       bool
+}
+
+// Phase is a single step of a request, Start is relative to its start
+type Phase struct {
+	Name     string
+	Start    time.Duration
+	Duration time.Duration
+}
+
+// Phases returns the steps of the request in the order they happened
+func (t Timing) Phases() []Phase {
+	phases := []Phase{
+		{Name: "DNS lookup", Duration: t.DNSLookup},
+		{Name: "TCP connect", Duration: t.TCPConnect},
+		{Name: "TLS handshake", Duration: t.TLSHandshake},
+		{Name: "Server processing", Duration: t.ServerProcessing},
+		{Name: "Content transfer", Duration: t.ContentTransfer},
+	}
+
+	// Anything not covered by a phase, e.g. writing the request, is added
+	// to the gap before server processing
+	var start time.Duration
+	for i := range phases {
+		if i == 3 {
+			start = max(start, t.FirstByte-t.ServerProcessing)
+		}
+		phases[i].Start = start
+		start += phases[i].Duration
+	}
+	return phases
+}
+
+// Waterfall renders every phase as a bar of at most width characters
+// starting where the phase started, followed by the total
+func (t Timing) Waterfall(width int) string {
+	var b strings.Builder
+
+	scale := 0.0
+	if t.Total > 0 {
+		scale = float64(width) / float64(t.Total)
+	}
+
+	for _, p := range t.Phases() {
+		offset := int(float64(p.Start) * scale)
+		length := int(float64(p.Duration) * scale)
+		if p.Duration > 0 && length == 0 {
+			length = 1
+		}
+		offset = min(offset, width-length)
+
+		bar := strings.Repeat(" ", max(0, offset)) + strings.Repeat("█", length)
+		fmt.Fprintf(&b, "%-18s %-*s %10s\n", p.Name, width, bar, FormatDuration(p.Duration))
+	}
+	fmt.Fprintf(&b, "%-18s %-*s %10s", "Total", width, "", FormatDuration(t.Total))
+	if t.ConnReused {
+		b.WriteString(" (connection reused)")
+	}
+
+	return b.String()
+}
+
+// FormatDuration rounds d to a precision that fits the order of magnitude
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	}
+	return d.Round(time.Microsecond).String()
+}
+
+// tracer collects the timestamps of a request through httptrace. Callbacks
+// may come from other goroutines, e.g. when dialing several addresses.
+type tracer struct {
+	mu sync.Mutex
+
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	now := func(ts *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		*ts = time.Now()
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { now(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { now(&t.dnsDone) },
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				now(&t.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() { now(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { now(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.gotConn = time.Now()
+			t.reused = info.Reused
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { now(&t.wroteRequest) },
+		GotFirstResponseByte: func() { now(&t.firstByte) },
+	}
+}
+
+// timing computes the phases of a request whose body was read at end
+func (t *tracer) timing(end time.Time) Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	between := func(from, to time.Time) time.Duration {
+		if from.IsZero() || to.IsZero() || to.Before(from) {
+			return 0
+		}
+		return to.Sub(from)
+	}
+
+	sent := t.wroteRequest
+	if sent.IsZero() {
+		sent = t.gotConn
+	}
+
+	return Timing{
+		DNSLookup:        between(t.dnsStart, t.dnsDone),
+		TCPConnect:       between(t.connectStart, t.connectDone),
+		TLSHandshake:     between(t.tlsStart, t.tlsDone),
+		ServerProcessing: between(sent, t.firstByte),
+		ContentTransfer:  between(t.firstByte, end),
+		FirstByte:        between(t.start, t.firstByte),
+		Total:            between(t.start, end),
+		ConnReused:       t.reused,
+	}
+}
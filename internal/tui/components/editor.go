@@ -0,0 +1,494 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/bata94/reqlab/pkgs/apiview"
+)
+
+const (
+	TabParams = iota
+	TabHeaders
+	TabBody
+	TabAuth
+)
+
+var editorTabs = []string{"Params", "Headers", "Body", "Auth"}
+
+// DefaultContentTypes are offered for the body besides the ones of the spec
+var DefaultContentTypes = []string{
+	"application/json",
+	"application/x-www-form-urlencoded",
+	"multipart/form-data",
+	"text/plain",
+	"application/xml",
+}
+
+var methodColor = map[apiview.HTTPMethod]lipgloss.Color{
+	apiview.GET:     lipgloss.Color("#61AFFE"),
+	apiview.POST:    lipgloss.Color("#49CC90"),
+	apiview.PUT:     lipgloss.Color("#FCA130"),
+	apiview.DELETE:  lipgloss.Color("#F93E3E"),
+	apiview.PATCH:   lipgloss.Color("#50E3C2"),
+	apiview.HEAD:    lipgloss.Color("#9012FE"),
+	apiview.OPTIONS: lipgloss.Color("#0D5AA7"),
+	apiview.CONNECT: lipgloss.Color("#EBEBEB"),
+	apiview.TRACE:   lipgloss.Color("#EBEBEB"),
+}
+
+// MethodBadge renders the method in the colors Swagger UI uses for it
+func MethodBadge(method apiview.HTTPMethod) string {
+	return lipgloss.NewStyle().
+		Bold(true).
+		Padding(0, 1).
+		Foreground(lipgloss.Color("#000000")).
+		Background(methodColor[method]).
+		Render(strings.ToUpper(method.String()))
+}
+
+// The auth inputs, which of them are shown depends on the auth type
+const (
+	authToken = iota
+	authUsername
+	authPassword
+	authName
+	authValue
+)
+
+var authFields = map[apiview.AuthType][]int{
+	apiview.AuthBearer: {authToken},
+	apiview.AuthBasic:  {authUsername, authPassword},
+	apiview.AuthAPIKey: {authName, authValue},
+}
+
+var authLabels = []string{"Token", "Username", "Password", "Name", "Value"}
+
+// RequestEditor edits the method, params, headers, body and auth of a
+// request, the URL is edited outside of it
+type RequestEditor struct {
+	Method apiview.HTTPMethod
+
+	keys         *EditorKeyMap
+	help         help.Model
+	tab          int
+	params       KVTable
+	headers      KVTable
+	body         textarea.Model
+	contentTypes []string
+	contentType  int
+	authType     apiview.AuthType
+	authInQuery  bool
+	authInputs   []textinput.Model
+	authField    int // Index into the fields of authType
+	authEditing  bool
+	width        int
+	height       int
+}
+
+type EditorKeyMap struct {
+	NextTab     key.Binding
+	PrevTab     key.Binding
+	Method      key.Binding
+	MethodBack  key.Binding
+	ContentType key.Binding
+	EditBody    key.Binding
+	AuthType    key.Binding
+	AuthIn      key.Binding
+	Up          key.Binding
+	Down        key.Binding
+	Edit        key.Binding
+	Done        key.Binding
+}
+
+func NewEditorKeyMap() *EditorKeyMap {
+	return &EditorKeyMap{
+		NextTab: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "next tab"),
+		),
+		PrevTab: key.NewBinding(
+			key.WithKeys("shift+tab"),
+			key.WithHelp("shift+tab", "prev tab"),
+		),
+		Method: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "method"),
+		),
+		MethodBack: key.NewBinding(
+			key.WithKeys("M"),
+			key.WithHelp("M", "prev method"),
+		),
+		ContentType: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "content type"),
+		),
+		EditBody: key.NewBinding(
+			key.WithKeys("enter", "i"),
+			key.WithHelp("enter", "edit body"),
+		),
+		AuthType: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "auth type"),
+		),
+		AuthIn: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "header/query"),
+		),
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		Edit: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "edit"),
+		),
+		Done: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "done"),
+		),
+	}
+}
+
+func NewRequestEditor() RequestEditor {
+	body := textarea.New()
+	body.Placeholder = "Request body"
+	body.ShowLineNumbers = true
+
+	inputs := make([]textinput.Model, len(authLabels))
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = ""
+		inputs[i].Placeholder = strings.ToLower(authLabels[i])
+	}
+	inputs[authPassword].EchoMode = textinput.EchoPassword
+
+	e := RequestEditor{
+		keys:         NewEditorKeyMap(),
+		help:         help.New(),
+		params:       NewKVTable(nil),
+		headers:      NewKVTable(nil),
+		body:         body,
+		contentTypes: DefaultContentTypes,
+		authInputs:   inputs,
+	}
+	e.SetSize(60, 10)
+	return e
+}
+
+// SetSize sets the size of the editor including its tab bar and help
+func (e *RequestEditor) SetSize(width, height int) {
+	e.width = width
+	e.height = height
+
+	content := e.contentHeight()
+	e.params.SetSize(width, content)
+	e.headers.SetSize(width, content)
+	e.body.SetWidth(width)
+	e.body.SetHeight(content - 1) // Below the content type
+	for i := range e.authInputs {
+		e.authInputs[i].Width = width - 14
+	}
+}
+
+// contentHeight is the height left for the active tab
+func (e RequestEditor) contentHeight() int {
+	return max(1, e.height-3)
+}
+
+// Editing reports whether text is being typed into the editor, it then needs
+// all keys
+func (e RequestEditor) Editing() bool {
+	return e.params.Editing() || e.headers.Editing() || e.body.Focused() || e.authEditing
+}
+
+// SetRequest loads r into the editor
+func (e *RequestEditor) SetRequest(r apiview.Request) {
+	e.Method = r.Method
+
+	var params []KVRow
+	for _, p := range r.Params {
+		row := KVRow{Key: p.Key, Value: p.Value, Enabled: p.Enabled}
+		if p.Path {
+			row.Note = "path"
+		}
+		params = append(params, row)
+	}
+	e.params.Rows = params
+	e.params.cursor = 0
+
+	var headers []KVRow
+	for _, h := range r.Headers {
+		headers = append(headers, KVRow{Key: h.Key, Value: h.Value, Enabled: h.Enabled})
+	}
+	e.headers.Rows = headers
+	e.headers.cursor = 0
+
+	e.body.SetValue(r.Body)
+	e.contentTypes = DefaultContentTypes
+	e.contentType = 0
+	if r.ContentType != "" {
+		e.contentType = -1
+		for i, t := range e.contentTypes {
+			if t == r.ContentType {
+				e.contentType = i
+			}
+		}
+		if e.contentType < 0 {
+			e.contentTypes = append([]string{r.ContentType}, DefaultContentTypes...)
+			e.contentType = 0
+		}
+	}
+
+	e.authType = r.Auth.Type
+	e.authInQuery = r.Auth.InQuery
+	e.authField = 0
+	e.authInputs[authToken].SetValue(r.Auth.Token)
+	e.authInputs[authUsername].SetValue(r.Auth.Username)
+	e.authInputs[authPassword].SetValue(r.Auth.Password)
+	e.authInputs[authName].SetValue(r.Auth.Name)
+	e.authInputs[authValue].SetValue(r.Auth.Value)
+}
+
+// Request returns the edited request for url
+func (e RequestEditor) Request(url string) apiview.Request {
+	r := apiview.Request{
+		Method:      e.Method,
+		URL:         url,
+		Body:        e.body.Value(),
+		ContentType: e.contentTypes[e.contentType],
+		Auth: apiview.Auth{
+			Type:     e.authType,
+			Token:    e.authInputs[authToken].Value(),
+			Username: e.authInputs[authUsername].Value(),
+			Password: e.authInputs[authPassword].Value(),
+			Name:     e.authInputs[authName].Value(),
+			Value:    e.authInputs[authValue].Value(),
+			InQuery:  e.authInQuery,
+		},
+	}
+
+	for _, row := range e.params.Rows {
+		r.Params = append(r.Params, apiview.Param{
+			Key:     row.Key,
+			Value:   row.Value,
+			Enabled: row.Enabled,
+			Path:    row.Note == "path",
+		})
+	}
+	for _, row := range e.headers.Rows {
+		r.Headers = append(r.Headers, apiview.Header{Key: row.Key, Value: row.Value, Enabled: row.Enabled})
+	}
+
+	return r
+}
+
+// CycleMethod selects the next method, or the previous one if step is -1
+func (e *RequestEditor) CycleMethod(step int) {
+	n := len(apiview.HTTPMethods)
+	for i, m := range apiview.HTTPMethods {
+		if m == e.Method {
+			e.Method = apiview.HTTPMethods[((i+step)%n+n)%n]
+			return
+		}
+	}
+}
+
+func (e RequestEditor) Update(msg tea.Msg) (RequestEditor, tea.Cmd) {
+	var cmd tea.Cmd
+
+	keyMsg, isKey := msg.(tea.KeyMsg)
+	if e.Editing() {
+		if isKey && key.Matches(keyMsg, e.keys.Done) && (e.body.Focused() || e.authEditing) {
+			e.body.Blur()
+			e.authEditing = false
+			e.authInputs[e.currentAuthInput()].Blur()
+			return e, nil
+		}
+		if isKey && key.Matches(keyMsg, e.keys.Edit) && e.authEditing {
+			e.authEditing = false
+			e.authInputs[e.currentAuthInput()].Blur()
+			return e, nil
+		}
+
+		switch {
+		case e.params.Editing():
+			e.params, cmd = e.params.Update(msg)
+		case e.headers.Editing():
+			e.headers, cmd = e.headers.Update(msg)
+		case e.body.Focused():
+			e.body, cmd = e.body.Update(msg)
+		case e.authEditing:
+			i := e.currentAuthInput()
+			e.authInputs[i], cmd = e.authInputs[i].Update(msg)
+		}
+		return e, cmd
+	}
+
+	if !isKey {
+		return e, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, e.keys.NextTab):
+		e.tab = (e.tab + 1) % len(editorTabs)
+		return e, nil
+	case key.Matches(keyMsg, e.keys.PrevTab):
+		e.tab = (e.tab + len(editorTabs) - 1) % len(editorTabs)
+		return e, nil
+	case key.Matches(keyMsg, e.keys.Method):
+		e.CycleMethod(1)
+		return e, nil
+	case key.Matches(keyMsg, e.keys.MethodBack):
+		e.CycleMethod(-1)
+		return e, nil
+	}
+
+	switch e.tab {
+	case TabParams:
+		e.params, cmd = e.params.Update(msg)
+	case TabHeaders:
+		e.headers, cmd = e.headers.Update(msg)
+	case TabBody:
+		switch {
+		case key.Matches(keyMsg, e.keys.ContentType):
+			e.contentType = (e.contentType + 1) % len(e.contentTypes)
+		case key.Matches(keyMsg, e.keys.EditBody):
+			cmd = e.body.Focus()
+		}
+	case TabAuth:
+		fields := authFields[e.authType]
+		switch {
+		case key.Matches(keyMsg, e.keys.AuthType):
+			e.authType = apiview.AuthTypes[(int(e.authType)+1)%len(apiview.AuthTypes)]
+			e.authField = 0
+		case key.Matches(keyMsg, e.keys.AuthIn):
+			if e.authType == apiview.AuthAPIKey {
+				e.authInQuery = !e.authInQuery
+			}
+		case key.Matches(keyMsg, e.keys.Up):
+			if e.authField > 0 {
+				e.authField--
+			}
+		case key.Matches(keyMsg, e.keys.Down):
+			if e.authField < len(fields)-1 {
+				e.authField++
+			}
+		case key.Matches(keyMsg, e.keys.Edit):
+			if len(fields) > 0 {
+				e.authEditing = true
+				cmd = e.authInputs[e.currentAuthInput()].Focus()
+			}
+		}
+	}
+
+	return e, cmd
+}
+
+// currentAuthInput returns the index of the selected auth input
+func (e RequestEditor) currentAuthInput() int {
+	fields := authFields[e.authType]
+	if len(fields) == 0 {
+		return authToken
+	}
+	return fields[min(e.authField, len(fields)-1)]
+}
+
+// ShortHelp returns the bindings of the active tab
+func (e RequestEditor) ShortHelp() []key.Binding {
+	bindings := []key.Binding{e.keys.NextTab, e.keys.Method}
+
+	switch e.tab {
+	case TabParams:
+		return append(e.params.ShortHelp(), bindings...)
+	case TabHeaders:
+		return append(e.headers.ShortHelp(), bindings...)
+	case TabBody:
+		if e.body.Focused() {
+			return []key.Binding{e.keys.Done}
+		}
+		return append([]key.Binding{e.keys.EditBody, e.keys.ContentType}, bindings...)
+	case TabAuth:
+		if e.authEditing {
+			return []key.Binding{e.keys.Edit, e.keys.Done}
+		}
+		authKeys := []key.Binding{e.keys.AuthType}
+		if e.authType == apiview.AuthAPIKey {
+			authKeys = append(authKeys, e.keys.AuthIn)
+		}
+		if len(authFields[e.authType]) > 0 {
+			authKeys = append(authKeys, e.keys.Edit)
+		}
+		return append(authKeys, bindings...)
+	}
+	return bindings
+}
+
+func (e RequestEditor) View() string {
+	var content string
+	switch e.tab {
+	case TabParams:
+		content = e.params.View()
+	case TabHeaders:
+		content = e.headers.View()
+	case TabBody:
+		content = lipgloss.JoinVertical(lipgloss.Left,
+			"Content-Type: "+e.contentTypes[e.contentType],
+			e.body.View(),
+		)
+	case TabAuth:
+		content = e.authView()
+	}
+
+	content = lipgloss.NewStyle().
+		Height(e.contentHeight()).
+		MaxHeight(e.contentHeight()).
+		Render(content)
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		RenderTabs(editorTabs, e.tab),
+		content,
+		"",
+		e.help.ShortHelpView(e.ShortHelp()),
+	)
+}
+
+func (e RequestEditor) authView() string {
+	lines := []string{"Type: " + e.authType.String()}
+	if e.authType == apiview.AuthAPIKey {
+		in := "header"
+		if e.authInQuery {
+			in = "query"
+		}
+		lines = append(lines, "In:   "+in)
+	}
+
+	for i, field := range authFields[e.authType] {
+		cursor := "  "
+		if i == e.authField {
+			cursor = kvCursorStyle.Render("> ")
+		}
+
+		value := e.authInputs[field].View()
+		if !e.authEditing || i != e.authField {
+			value = e.authInputs[field].Value()
+			if field == authPassword || field == authToken {
+				value = strings.Repeat("•", len([]rune(value)))
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%s%-10s %s", cursor, authLabels[field]+":", value))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,172 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/bata94/reqlab/internal/format"
+)
+
+var (
+	hlKeyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#61AFFE"))
+	hlStringStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#49CC90"))
+	hlNumberStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FCA130"))
+	hlLiteralStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#C678DD"))
+	hlPunctStyle   = lipgloss.NewStyle().Faint(true)
+	hlTagStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#F93E3E"))
+	hlAttrStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#FCA130"))
+	hlCommentStyle = lipgloss.NewStyle().Faint(true).Italic(true)
+)
+
+// Highlight colors formatted text of the given kind, text of other kinds is
+// returned unchanged
+func Highlight(kind format.Kind, text string) string {
+	switch kind {
+	case format.KindJSON:
+		return highlightJSON(text)
+	case format.KindXML, format.KindHTML:
+		return highlightMarkup(text)
+	case format.KindForm:
+		return highlightForm(text)
+	}
+	return text
+}
+
+// highlightJSON colors the tokens of (indented) JSON, a string followed by
+// a colon is a key
+func highlightJSON(text string) string {
+	var b strings.Builder
+	b.Grow(len(text) * 2)
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(text) && text[end] != '"' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(text))
+
+			rest := strings.TrimLeft(text[end:], " \t")
+			if strings.HasPrefix(rest, ":") {
+				b.WriteString(hlKeyStyle.Render(text[i:end]))
+			} else {
+				b.WriteString(hlStringStyle.Render(text[i:end]))
+			}
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(text) && strings.IndexByte("0123456789.eE+-", text[end]) >= 0 {
+				end++
+			}
+			b.WriteString(hlNumberStyle.Render(text[i:end]))
+			i = end
+		case strings.HasPrefix(text[i:], "true"), strings.HasPrefix(text[i:], "null"):
+			b.WriteString(hlLiteralStyle.Render(text[i : i+4]))
+			i += 4
+		case strings.HasPrefix(text[i:], "false"):
+			b.WriteString(hlLiteralStyle.Render(text[i : i+5]))
+			i += 5
+		case strings.IndexByte("{}[],:", c) >= 0:
+			b.WriteString(hlPunctStyle.Render(string(c)))
+			i++
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// highlightMarkup colors tags, attributes and comments of XML and HTML
+func highlightMarkup(text string) string {
+	var b strings.Builder
+	b.Grow(len(text) * 2)
+
+	for i := 0; i < len(text); {
+		start := strings.IndexByte(text[i:], '<')
+		if start < 0 {
+			b.WriteString(text[i:])
+			break
+		}
+		b.WriteString(text[i : i+start])
+		i += start
+
+		if strings.HasPrefix(text[i:], "<!--") {
+			end := strings.Index(text[i:], "-->")
+			if end < 0 {
+				end = len(text) - i - 3
+			}
+			b.WriteString(hlCommentStyle.Render(text[i : i+end+3]))
+			i += end + 3
+			continue
+		}
+
+		end := strings.IndexByte(text[i:], '>')
+		if end < 0 {
+			b.WriteString(text[i:])
+			break
+		}
+		b.WriteString(highlightTag(text[i : i+end+1]))
+		i += end + 1
+	}
+
+	return b.String()
+}
+
+// highlightTag colors a single tag like <a href="x">
+func highlightTag(tag string) string {
+	// The name runs until the first space or the end of the tag
+	nameEnd := strings.IndexAny(tag, " \t\n")
+	if nameEnd < 0 {
+		return hlTagStyle.Render(tag)
+	}
+
+	var b strings.Builder
+	b.WriteString(hlTagStyle.Render(tag[:nameEnd]))
+
+	rest := tag[nameEnd : len(tag)-1]
+	for len(rest) > 0 {
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			b.WriteString(hlAttrStyle.Render(rest))
+			break
+		}
+		b.WriteString(hlAttrStyle.Render(rest[:eq]))
+		b.WriteString(hlPunctStyle.Render("="))
+		rest = rest[eq+1:]
+
+		// Quoted value, unquoted ones run until the next space
+		valueEnd := strings.IndexAny(rest, " \t\n")
+		if len(rest) > 0 && (rest[0] == '"' || rest[0] == '\'') {
+			if closing := strings.IndexByte(rest[1:], rest[0]); closing >= 0 {
+				valueEnd = closing + 2
+			}
+		}
+		if valueEnd < 0 {
+			valueEnd = len(rest)
+		}
+		b.WriteString(hlStringStyle.Render(rest[:valueEnd]))
+		rest = rest[valueEnd:]
+	}
+
+	b.WriteString(hlTagStyle.Render(tag[len(tag)-1:]))
+	return b.String()
+}
+
+// highlightForm colors the keys of a key = value table
+func highlightForm(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if key, value, ok := strings.Cut(line, " = "); ok {
+			lines[i] = hlKeyStyle.Render(key) + hlPunctStyle.Render(" = ") + value
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,593 @@
+package components
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+var (
+	treeSelectedStyle = lipgloss.NewStyle().Reverse(true)
+	treeSummaryStyle  = lipgloss.NewStyle().Faint(true)
+)
+
+type nodeKind int
+
+const (
+	nodeObject nodeKind = iota
+	nodeArray
+	nodeString
+	nodeNumber
+	nodeBool
+	nodeNull
+)
+
+// treeNode is a value of the decoded JSON, objects keep the order of their
+// keys
+type treeNode struct {
+	kind     nodeKind
+	key      string // Key in the parent object or index in the parent array
+	pointer  string // JSON pointer from the root
+	value    interface{}
+	children []*treeNode
+	parent   *treeNode
+	depth    int
+	expanded bool
+}
+
+// JSONTree shows a JSON document as a tree of collapsible nodes
+type JSONTree struct {
+	root    *treeNode
+	lines   []*treeNode // Visible nodes in the order they are shown
+	cursor  int
+	offset  int
+	width   int
+	height  int
+	keys    *JSONTreeKeyMap
+	jumping bool
+	jump    textinput.Model
+	status  string
+}
+
+type JSONTreeKeyMap struct {
+	Up          key.Binding
+	Down        key.Binding
+	PageUp      key.Binding
+	PageDown    key.Binding
+	Expand      key.Binding
+	Collapse    key.Binding
+	Toggle      key.Binding
+	ExpandAll   key.Binding
+	CollapseAll key.Binding
+	Jump        key.Binding
+	CopyPointer key.Binding
+	CopyValue   key.Binding
+}
+
+func NewJSONTreeKeyMap() *JSONTreeKeyMap {
+	return &JSONTreeKeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		PageUp: key.NewBinding(
+			key.WithKeys("pgup", "b"),
+			key.WithHelp("pgup", "page up"),
+		),
+		PageDown: key.NewBinding(
+			key.WithKeys("pgdown", "f"),
+			key.WithHelp("pgdn", "page down"),
+		),
+		Expand: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithHelp("→/l", "expand"),
+		),
+		Collapse: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("←/h", "collapse"),
+		),
+		Toggle: key.NewBinding(
+			key.WithKeys("enter", " "),
+			key.WithHelp("enter", "toggle"),
+		),
+		ExpandAll: key.NewBinding(
+			key.WithKeys("E"),
+			key.WithHelp("E", "expand all"),
+		),
+		CollapseAll: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "collapse all"),
+		),
+		Jump: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "jump to path"),
+		),
+		CopyPointer: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy pointer"),
+		),
+		CopyValue: key.NewBinding(
+			key.WithKeys("Y"),
+			key.WithHelp("Y", "copy value"),
+		),
+	}
+}
+
+// CopiedMsg reports the result of copying to the clipboard
+type CopiedMsg struct {
+	What string
+	Err  error
+}
+
+// NewJSONTree decodes body into a tree, the first level is expanded
+func NewJSONTree(body []byte) (JSONTree, error) {
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+
+	root, err := decodeNode(d, nil, "", "")
+	if err != nil {
+		return JSONTree{}, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return JSONTree{}, fmt.Errorf("unexpected data after the JSON value")
+	}
+
+	root.expanded = true
+	for _, child := range root.children {
+		child.expanded = true
+	}
+
+	jump := textinput.New()
+	jump.Prompt = "path: "
+	jump.Placeholder = "/items/0/name or items[0].name"
+
+	t := JSONTree{
+		root:   root,
+		keys:   NewJSONTreeKeyMap(),
+		jump:   jump,
+		width:  80,
+		height: 20,
+	}
+	t.refresh()
+	return t, nil
+}
+
+func decodeNode(d *json.Decoder, parent *treeNode, key, pointer string) (*treeNode, error) {
+	tok, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &treeNode{key: key, pointer: pointer, parent: parent}
+	if parent != nil {
+		n.depth = parent.depth + 1
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '{' {
+			n.kind = nodeObject
+		} else {
+			n.kind = nodeArray
+		}
+		for i := 0; d.More(); i++ {
+			childKey := strconv.Itoa(i)
+			if n.kind == nodeObject {
+				keyTok, err := d.Token()
+				if err != nil {
+					return nil, err
+				}
+				childKey = keyTok.(string)
+			}
+
+			child, err := decodeNode(d, n, childKey, pointer+"/"+escapeToken(childKey))
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		}
+		// The closing delimiter
+		if _, err := d.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		n.kind, n.value = nodeString, v
+	case json.Number:
+		n.kind, n.value = nodeNumber, v
+	case bool:
+		n.kind, n.value = nodeBool, v
+	case nil:
+		n.kind = nodeNull
+	}
+
+	return n, nil
+}
+
+func escapeToken(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func unescapeToken(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
+
+func (t *JSONTree) SetSize(width, height int) {
+	t.width = width
+	t.height = height
+	t.jump.Width = width - 10
+	t.scroll()
+}
+
+// Editing reports whether a path is being typed in, keys are then needed
+func (t JSONTree) Editing() bool {
+	return t.jumping
+}
+
+// refresh rebuilds the visible lines after nodes were expanded or collapsed
+func (t *JSONTree) refresh() {
+	t.lines = t.lines[:0]
+
+	var walk func(n *treeNode)
+	walk = func(n *treeNode) {
+		t.lines = append(t.lines, n)
+		if n.expanded {
+			for _, child := range n.children {
+				walk(child)
+			}
+		}
+	}
+	walk(t.root)
+
+	t.cursor = min(t.cursor, len(t.lines)-1)
+	t.scroll()
+}
+
+// viewHeight is the number of lines left for nodes
+func (t JSONTree) viewHeight() int {
+	return max(1, t.height-1)
+}
+
+// scroll moves the view so the cursor is visible
+func (t *JSONTree) scroll() {
+	h := t.viewHeight()
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+h {
+		t.offset = t.cursor - h + 1
+	}
+}
+
+func (t *JSONTree) selectNode(n *treeNode) {
+	for p := n.parent; p != nil; p = p.parent {
+		p.expanded = true
+	}
+	t.refresh()
+	for i, line := range t.lines {
+		if line == n {
+			t.cursor = i
+		}
+	}
+	t.scroll()
+}
+
+func setExpanded(n *treeNode, expanded bool) {
+	n.expanded = expanded
+	for _, child := range n.children {
+		setExpanded(child, expanded)
+	}
+}
+
+func (t JSONTree) Update(msg tea.Msg) (JSONTree, tea.Cmd) {
+	if copied, ok := msg.(CopiedMsg); ok {
+		if copied.Err != nil {
+			t.status = "Copy failed: " + copied.Err.Error()
+		} else {
+			t.status = "Copied " + copied.What
+		}
+		return t, nil
+	}
+
+	keyMsg, isKey := msg.(tea.KeyMsg)
+
+	if t.jumping {
+		if isKey {
+			switch keyMsg.String() {
+			case "enter":
+				t.jumping = false
+				t.jump.Blur()
+				t.jumpTo(t.jump.Value())
+				return t, nil
+			case "esc":
+				t.jumping = false
+				t.jump.Blur()
+				return t, nil
+			}
+		}
+		var cmd tea.Cmd
+		t.jump, cmd = t.jump.Update(msg)
+		return t, cmd
+	}
+
+	if !isKey {
+		return t, nil
+	}
+
+	t.status = ""
+	n := t.lines[t.cursor]
+	switch {
+	case key.Matches(keyMsg, t.keys.Up):
+		t.cursor = max(0, t.cursor-1)
+	case key.Matches(keyMsg, t.keys.Down):
+		t.cursor = min(len(t.lines)-1, t.cursor+1)
+	case key.Matches(keyMsg, t.keys.PageUp):
+		t.cursor = max(0, t.cursor-t.viewHeight())
+	case key.Matches(keyMsg, t.keys.PageDown):
+		t.cursor = min(len(t.lines)-1, t.cursor+t.viewHeight())
+	case key.Matches(keyMsg, t.keys.Expand):
+		if len(n.children) > 0 {
+			n.expanded = true
+			t.refresh()
+		}
+	case key.Matches(keyMsg, t.keys.Collapse):
+		// Collapsed nodes and leaves move up to their parent
+		if n.expanded && len(n.children) > 0 {
+			n.expanded = false
+			t.refresh()
+		} else if n.parent != nil {
+			t.selectNode(n.parent)
+		}
+	case key.Matches(keyMsg, t.keys.Toggle):
+		if len(n.children) > 0 {
+			n.expanded = !n.expanded
+			t.refresh()
+		}
+	case key.Matches(keyMsg, t.keys.ExpandAll):
+		setExpanded(n, true)
+		t.refresh()
+	case key.Matches(keyMsg, t.keys.CollapseAll):
+		setExpanded(n, false)
+		t.refresh()
+	case key.Matches(keyMsg, t.keys.Jump):
+		t.jumping = true
+		t.jump.SetValue("")
+		return t, t.jump.Focus()
+	case key.Matches(keyMsg, t.keys.CopyPointer):
+		pointer := n.pointer
+		if pointer == "" {
+			pointer = "/"
+		}
+		return t, copyToClipboard(pointer, "pointer "+pointer)
+	case key.Matches(keyMsg, t.keys.CopyValue):
+		return t, copyToClipboard(n.text(), "value of "+n.pointer)
+	}
+
+	t.scroll()
+	return t, nil
+}
+
+// jumpTo selects the node at path, a JSON pointer like /a/0/b or a dotted
+// path like a[0].b. If it does not exist the deepest existing node is
+// selected.
+func (t *JSONTree) jumpTo(path string) {
+	n := t.root
+	for _, token := range splitPath(path) {
+		var next *treeNode
+		for _, child := range n.children {
+			if child.key == token {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			t.status = fmt.Sprintf("%q not found in %s", token, n.displayPointer())
+			break
+		}
+		n = next
+	}
+	t.selectNode(n)
+}
+
+func splitPath(path string) []string {
+	path = strings.TrimSpace(path)
+
+	if strings.HasPrefix(path, "/") {
+		var tokens []string
+		for _, token := range strings.Split(path[1:], "/") {
+			tokens = append(tokens, unescapeToken(token))
+		}
+		return tokens
+	}
+
+	path = strings.TrimPrefix(path, "$")
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+
+	var tokens []string
+	for _, token := range strings.Split(path, ".") {
+		if token = strings.Trim(token, `"'`); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+func (n *treeNode) displayPointer() string {
+	if n.pointer == "" {
+		return "/"
+	}
+	return n.pointer
+}
+
+// text returns the value of the node to be copied, strings without quotes
+// and everything else as JSON
+func (n *treeNode) text() string {
+	if n.kind == nodeString {
+		return n.value.(string)
+	}
+
+	var b strings.Builder
+	n.writeJSON(&b, "")
+	return b.String()
+}
+
+func (n *treeNode) writeJSON(b *strings.Builder, indent string) {
+	switch n.kind {
+	case nodeObject, nodeArray:
+		openDelim, closeDelim := "{", "}"
+		if n.kind == nodeArray {
+			openDelim, closeDelim = "[", "]"
+		}
+		if len(n.children) == 0 {
+			b.WriteString(openDelim + closeDelim)
+			return
+		}
+
+		b.WriteString(openDelim + "\n")
+		for i, child := range n.children {
+			b.WriteString(indent + "  ")
+			if n.kind == nodeObject {
+				b.WriteString(jsonText(child.key) + ": ")
+			}
+			child.writeJSON(b, indent+"  ")
+			if i < len(n.children)-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + closeDelim)
+	case nodeNull:
+		b.WriteString("null")
+	default:
+		b.WriteString(jsonText(n.value))
+	}
+}
+
+// jsonText encodes a scalar without escaping HTML characters like & and <
+func jsonText(v interface{}) string {
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// copyToClipboard copies text to the system clipboard, without one it falls
+// back to the OSC 52 escape sequence most terminals understand
+func copyToClipboard(text, what string) tea.Cmd {
+	return func() tea.Msg {
+		if err := clipboard.WriteAll(text); err != nil {
+			termenv.NewOutput(os.Stdout).Copy(text)
+		}
+		return CopiedMsg{What: what}
+	}
+}
+
+// ShortHelp returns the most important bindings
+func (t JSONTree) ShortHelp() []key.Binding {
+	return []key.Binding{t.keys.Toggle, t.keys.Jump, t.keys.CopyPointer, t.keys.CopyValue, t.keys.ExpandAll}
+}
+
+func (t JSONTree) View() string {
+	end := min(len(t.lines), t.offset+t.viewHeight())
+
+	lines := make([]string, 0, t.viewHeight()+1)
+	for i := t.offset; i < end; i++ {
+		lines = append(lines, t.renderNode(t.lines[i], i == t.cursor))
+	}
+	for len(lines) < t.viewHeight() {
+		lines = append(lines, "")
+	}
+
+	switch {
+	case t.jumping:
+		lines = append(lines, t.jump.View())
+	case t.status != "":
+		lines = append(lines, statusMessageStyle(t.status))
+	default:
+		lines = append(lines, treeSummaryStyle.Render(t.lines[t.cursor].displayPointer()))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (t JSONTree) renderNode(n *treeNode, selected bool) string {
+	marker := "  "
+	if len(n.children) > 0 {
+		marker = "▸ "
+		if n.expanded {
+			marker = "▾ "
+		}
+	}
+
+	label := ""
+	if n.parent != nil {
+		if n.parent.kind == nodeArray {
+			label = "[" + n.key + "]: "
+		} else {
+			label = n.key + ": "
+		}
+	}
+
+	var value string
+	switch n.kind {
+	case nodeObject:
+		value = fmt.Sprintf("{%d %s}", len(n.children), plural(len(n.children), "key", "keys"))
+	case nodeArray:
+		value = fmt.Sprintf("[%d %s]", len(n.children), plural(len(n.children), "item", "items"))
+	case nodeNull:
+		value = "null"
+	default:
+		value = jsonText(n.value)
+	}
+
+	// Truncate before styling, the width of styled text is harder to cut
+	prefix := strings.Repeat("  ", n.depth) + marker + label
+	room := t.width - lipgloss.Width(prefix)
+	if runes := []rune(value); room > 1 && len(runes) > room {
+		value = string(runes[:room-1]) + "…"
+	}
+
+	if selected {
+		return treeSelectedStyle.Render(prefix + value)
+	}
+
+	switch n.kind {
+	case nodeObject, nodeArray:
+		value = treeSummaryStyle.Render(value)
+	case nodeString:
+		value = hlStringStyle.Render(value)
+	case nodeNumber:
+		value = hlNumberStyle.Render(value)
+	default:
+		value = hlLiteralStyle.Render(value)
+	}
+	if label != "" {
+		label = hlKeyStyle.Render(label)
+	}
+	return strings.Repeat("  ", n.depth) + marker + label + value
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,283 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	kvCursorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#EE6FF8"))
+	kvDisabledStyle = lipgloss.NewStyle().Faint(true)
+	kvNoteStyle     = lipgloss.NewStyle().Faint(true).Italic(true)
+)
+
+// KVRow is a row of a KVTable, Note is shown next to it, e.g. "path"
+type KVRow struct {
+	Key     string
+	Value   string
+	Enabled bool
+	Note    string
+}
+
+// KVTable is an editable list of key/value rows that can be toggled on and
+// off, used for query params and headers
+type KVTable struct {
+	Rows []KVRow
+
+	keys       *KVKeyMap
+	cursor     int
+	editing    bool
+	keyInput   textinput.Model
+	valueInput textinput.Model
+	width      int
+	height     int
+}
+
+type KVKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Toggle key.Binding
+	Add    key.Binding
+	Remove key.Binding
+	Edit   key.Binding
+	Next   key.Binding
+	Save   key.Binding
+	Cancel key.Binding
+}
+
+func NewKVKeyMap() *KVKeyMap {
+	return &KVKeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		Toggle: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "toggle"),
+		),
+		Add: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "add"),
+		),
+		Remove: key.NewBinding(
+			key.WithKeys("x", "delete"),
+			key.WithHelp("x", "delete"),
+		),
+		Edit: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "edit"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("tab", "shift+tab"),
+			key.WithHelp("tab", "key/value"),
+		),
+		Save: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "save"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+	}
+}
+
+func NewKVTable(rows []KVRow) KVTable {
+	keyInput := textinput.New()
+	keyInput.Placeholder = "key"
+	keyInput.Prompt = ""
+	valueInput := textinput.New()
+	valueInput.Placeholder = "value"
+	valueInput.Prompt = ""
+
+	return KVTable{
+		Rows:       rows,
+		keys:       NewKVKeyMap(),
+		keyInput:   keyInput,
+		valueInput: valueInput,
+		width:      60,
+		height:     8,
+	}
+}
+
+// Editing reports whether a row is being edited, keys are then typed into it
+func (t KVTable) Editing() bool {
+	return t.editing
+}
+
+func (t *KVTable) SetSize(width, height int) {
+	t.width = width
+	t.height = height
+	t.keyInput.Width = width/3 - 2
+	t.valueInput.Width = width - width/3 - 8
+}
+
+// ShortHelp returns the bindings of the current mode
+func (t KVTable) ShortHelp() []key.Binding {
+	if t.editing {
+		return []key.Binding{t.keys.Next, t.keys.Save, t.keys.Cancel}
+	}
+	return []key.Binding{t.keys.Toggle, t.keys.Add, t.keys.Edit, t.keys.Remove}
+}
+
+func (t KVTable) Update(msg tea.Msg) (KVTable, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok && !t.editing {
+		return t, nil
+	}
+
+	if t.editing {
+		switch {
+		case !ok:
+			// Cursor blinks and the like
+		case key.Matches(keyMsg, t.keys.Save):
+			t.Rows[t.cursor].Key = strings.TrimSpace(t.keyInput.Value())
+			t.Rows[t.cursor].Value = t.valueInput.Value()
+			t.stopEditing()
+			return t, nil
+		case key.Matches(keyMsg, t.keys.Cancel):
+			// A row that was just added and never saved is dropped again
+			if t.Rows[t.cursor].Key == "" && t.Rows[t.cursor].Value == "" {
+				t.remove()
+			}
+			t.stopEditing()
+			return t, nil
+		case key.Matches(keyMsg, t.keys.Next):
+			if t.keyInput.Focused() {
+				t.keyInput.Blur()
+				return t, t.valueInput.Focus()
+			}
+			t.valueInput.Blur()
+			return t, t.keyInput.Focus()
+		}
+
+		var cmd tea.Cmd
+		if t.keyInput.Focused() {
+			t.keyInput, cmd = t.keyInput.Update(msg)
+		} else {
+			t.valueInput, cmd = t.valueInput.Update(msg)
+		}
+		return t, cmd
+	}
+
+	switch {
+	case key.Matches(keyMsg, t.keys.Up):
+		if t.cursor > 0 {
+			t.cursor--
+		}
+	case key.Matches(keyMsg, t.keys.Down):
+		if t.cursor < len(t.Rows)-1 {
+			t.cursor++
+		}
+	case key.Matches(keyMsg, t.keys.Toggle):
+		if len(t.Rows) > 0 {
+			t.Rows[t.cursor].Enabled = !t.Rows[t.cursor].Enabled
+		}
+	case key.Matches(keyMsg, t.keys.Remove):
+		t.remove()
+	case key.Matches(keyMsg, t.keys.Add):
+		t.Rows = append(t.Rows, KVRow{Enabled: true})
+		t.cursor = len(t.Rows) - 1
+		return t, t.startEditing()
+	case key.Matches(keyMsg, t.keys.Edit):
+		if len(t.Rows) > 0 {
+			return t, t.startEditing()
+		}
+	}
+
+	return t, nil
+}
+
+func (t *KVTable) startEditing() tea.Cmd {
+	row := t.Rows[t.cursor]
+	t.editing = true
+	t.keyInput.SetValue(row.Key)
+	t.valueInput.SetValue(row.Value)
+
+	// Rows from the spec already have a key, go straight to the value
+	if row.Key != "" {
+		t.keyInput.Blur()
+		return t.valueInput.Focus()
+	}
+	t.valueInput.Blur()
+	return t.keyInput.Focus()
+}
+
+func (t *KVTable) stopEditing() {
+	t.editing = false
+	t.keyInput.Blur()
+	t.valueInput.Blur()
+}
+
+func (t *KVTable) remove() {
+	if len(t.Rows) == 0 {
+		return
+	}
+	t.Rows = append(t.Rows[:t.cursor], t.Rows[t.cursor+1:]...)
+	if t.cursor >= len(t.Rows) && t.cursor > 0 {
+		t.cursor--
+	}
+}
+
+func (t KVTable) View() string {
+	if len(t.Rows) == 0 {
+		return kvDisabledStyle.Render("No entries, press a to add one")
+	}
+
+	// Scroll so the cursor stays visible
+	start := 0
+	if t.height > 0 && t.cursor >= t.height {
+		start = t.cursor - t.height + 1
+	}
+	end := len(t.Rows)
+	if t.height > 0 && end > start+t.height {
+		end = start + t.height
+	}
+
+	keyWidth := t.width / 3
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		row := t.Rows[i]
+		cursor := "  "
+		if i == t.cursor {
+			cursor = kvCursorStyle.Render("> ")
+		}
+
+		check := "[ ]"
+		if row.Enabled {
+			check = "[x]"
+		}
+
+		var line string
+		if t.editing && i == t.cursor {
+			line = fmt.Sprintf("%s %s = %s", check,
+				lipgloss.NewStyle().Width(keyWidth).Render(t.keyInput.View()),
+				t.valueInput.View())
+		} else {
+			line = fmt.Sprintf("%s %s = %s", check,
+				lipgloss.NewStyle().Width(keyWidth).Render(row.Key),
+				row.Value)
+			if !row.Enabled {
+				line = kvDisabledStyle.Render(line)
+			}
+		}
+		if row.Note != "" {
+			line += " " + kvNoteStyle.Render(row.Note)
+		}
+
+		b.WriteString(cursor + line)
+		if i < end-1 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
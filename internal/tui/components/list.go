@@ -1,10 +1,17 @@
 package components
 
 import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/bata94/reqlab/pkgs/apiview"
 )
 
 var (
@@ -15,33 +22,113 @@ var (
 
 type ListComponent struct{}
 
+// Item is an entry of the endpoint list, Endpoint is nil for plain items
 type Item struct {
-	Title       string
-	Description string
+	title       string
+	description string
+	Tag         string
+	Endpoint    *apiview.Endpoint
+}
+
+// NewItem creates a plain list item
+func NewItem(title, description string) Item {
+	return Item{title: title, description: description}
+}
+
+// NewEndpointItem creates the list item of an endpoint listed under tag
+func NewEndpointItem(e apiview.Endpoint, tag string) Item {
+	description := e.Summary
+	if tag != "" {
+		description = fmt.Sprintf("[%s] %s", tag, description)
+	}
+	if e.Deprecated {
+		description = "(deprecated) " + description
+	}
+
+	return Item{
+		title:       fmt.Sprintf("%-7s %s", strings.ToUpper(e.Method.String()), e.Path),
+		description: strings.TrimSpace(description),
+		Tag:         tag,
+		Endpoint:    &e,
+	}
 }
 
-// func (i Item) Title() string       { return i.title }
-// func (i Item) Description() string { return i.description }
-func (i Item) FilterValue() string { return i.Title }
+func (i Item) Title() string       { return i.title }
+func (i Item) Description() string { return i.description }
+
+// FilterValue starts with the title so the matches highlighted in it line
+// up, the tag and summary can be filtered for as well
+func (i Item) FilterValue() string {
+	if i.Endpoint == nil {
+		return i.title
+	}
+	return strings.Join([]string{i.title, i.Tag, i.Endpoint.Summary}, " ")
+}
+
+// EndpointItems turns endpoints into list items grouped by tag. Groups follow
+// tagOrder, other tags are sorted after them and untagged endpoints come
+// last. An endpoint with several tags is listed in each of their groups.
+func EndpointItems(endpoints []apiview.Endpoint, tagOrder []string) []list.Item {
+	groups := map[string][]list.Item{}
+	for _, e := range endpoints {
+		tags := e.Tags
+		if len(tags) == 0 {
+			tags = []string{""}
+		}
+		for _, tag := range tags {
+			groups[tag] = append(groups[tag], NewEndpointItem(e, tag))
+		}
+	}
+
+	var items []list.Item
+	for _, tag := range Tags(endpoints, tagOrder) {
+		items = append(items, groups[tag]...)
+		delete(groups, tag)
+	}
+	return append(items, groups[""]...)
+}
+
+// Tags returns the tags used by endpoints, ordered like EndpointItems groups
+// them
+func Tags(endpoints []apiview.Endpoint, tagOrder []string) []string {
+	used := map[string]bool{}
+	for _, e := range endpoints {
+		for _, tag := range e.Tags {
+			used[tag] = true
+		}
+	}
+
+	var tags []string
+	for _, tag := range tagOrder {
+		if used[tag] {
+			tags = append(tags, tag)
+			delete(used, tag)
+		}
+	}
+
+	var rest []string
+	for tag := range used {
+		rest = append(rest, tag)
+	}
+	sort.Strings(rest)
+
+	return append(tags, rest...)
+}
 
 type ListKeyMap struct {
-	ToggleSpinner    key.Binding
 	ToggleTitleBar   key.Binding
 	ToggleStatusBar  key.Binding
 	TogglePagination key.Binding
 	ToggleHelpMenu   key.Binding
-	InsertItem       key.Binding
+	CycleTag         key.Binding
+	LoadTest         key.Binding
 }
 
 func NewListKeyMap() *ListKeyMap {
 	return &ListKeyMap{
-		InsertItem: key.NewBinding(
-			key.WithKeys("a"),
-			key.WithHelp("a", "add item"),
-		),
-		ToggleSpinner: key.NewBinding(
-			key.WithKeys("s"),
-			key.WithHelp("s", "toggle spinner"),
+		CycleTag: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "next tag"),
 		),
 		ToggleTitleBar: key.NewBinding(
 			key.WithKeys("T"),
@@ -59,17 +146,39 @@ func NewListKeyMap() *ListKeyMap {
 			key.WithKeys("H"),
 			key.WithHelp("H", "toggle help"),
 		),
+		LoadTest: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "load test"),
+		),
+	}
+}
+
+// ItemDelegate renders the list items, deprecated endpoints are struck
+// through
+type ItemDelegate struct {
+	list.DefaultDelegate
+}
+
+func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if i, ok := item.(Item); ok && i.Endpoint != nil && i.Endpoint.Deprecated {
+		dd := d.DefaultDelegate
+		dd.Styles.NormalTitle = dd.Styles.NormalTitle.Strikethrough(true).Faint(true)
+		dd.Styles.SelectedTitle = dd.Styles.SelectedTitle.Strikethrough(true)
+		dd.Styles.DimmedTitle = dd.Styles.DimmedTitle.Strikethrough(true)
+		dd.Render(w, m, index, item)
+		return
 	}
+	d.DefaultDelegate.Render(w, m, index, item)
 }
 
-func NewItemDelegate(keys *DelegateKeyMap) list.DefaultDelegate {
+func NewItemDelegate(keys *DelegateKeyMap) ItemDelegate {
 	d := list.NewDefaultDelegate()
 
 	d.UpdateFunc = func(msg tea.Msg, m *list.Model) tea.Cmd {
 		var title string
 
 		if i, ok := m.SelectedItem().(Item); ok {
-			title = i.Title
+			title = i.Title()
 		} else {
 			return nil
 		}
@@ -103,7 +212,7 @@ func NewItemDelegate(keys *DelegateKeyMap) list.DefaultDelegate {
 		return [][]key.Binding{help}
 	}
 
-	return d
+	return ItemDelegate{d}
 }
 
 type DelegateKeyMap struct {
@@ -0,0 +1,182 @@
+package components
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/bata94/reqlab/internal/format"
+	"github.com/bata94/reqlab/internal/request"
+)
+
+const (
+	RespTabBody = iota
+	RespTabHeaders
+	RespTabCookies
+	RespTabTiming
+	RespTabRaw
+)
+
+// ResponseTabs are the titles of the tabs of the response pane
+var ResponseTabs = []string{"Body", "Headers", "Cookies", "Timing", "Raw"}
+
+var headerKeyStyle = lipgloss.NewStyle().Bold(true)
+
+const (
+	// LazyFormatSize is the body size from which formatting should happen
+	// in the background
+	LazyFormatSize = 64 << 10
+	// HighlightLimit is the size up to which formatted bodies are colored
+	HighlightLimit = 1 << 20
+)
+
+// PrettyBody formats and highlights the body of resp according to its
+// content type, a body that does not parse is returned raw
+func PrettyBody(resp *request.Response) string {
+	kind := format.KindOf(resp.ContentType())
+
+	pretty, err := format.Pretty(kind, resp.Body)
+	if err != nil {
+		return string(resp.Body)
+	}
+	if len(pretty) > HighlightLimit {
+		return pretty
+	}
+	return Highlight(kind, pretty)
+}
+
+// StatusBadge renders the status colored by its class, e.g. green for 2xx
+func StatusBadge(code int, status string) string {
+	color := lipgloss.Color("#EBEBEB")
+	switch {
+	case code >= 500:
+		color = lipgloss.Color("#F93E3E")
+	case code >= 400:
+		color = lipgloss.Color("#FCA130")
+	case code >= 300:
+		color = lipgloss.Color("#61AFFE")
+	case code >= 200:
+		color = lipgloss.Color("#49CC90")
+	}
+
+	return lipgloss.NewStyle().
+		Bold(true).
+		Padding(0, 1).
+		Foreground(lipgloss.Color("#000000")).
+		Background(color).
+		Render(status)
+}
+
+// ResponseSummary renders the status badge, size and content type of resp
+func ResponseSummary(resp *request.Response) string {
+	return fmt.Sprintf("%s %s · %s + %s headers · %s",
+		StatusBadge(resp.StatusCode, resp.Status),
+		resp.Proto,
+		request.FormatSize(len(resp.Body)),
+		request.FormatSize(resp.HeaderSize()),
+		resp.ContentType(),
+	)
+}
+
+// ResponseContent renders tab of resp for a pane of width characters
+func ResponseContent(resp *request.Response, tab int, width int) string {
+	switch tab {
+	case RespTabHeaders:
+		return headersContent(resp)
+	case RespTabCookies:
+		return cookiesContent(resp)
+	case RespTabTiming:
+		return resp.Timing.Waterfall(max(10, width-32))
+	case RespTabRaw:
+		return strings.ReplaceAll(string(resp.Raw()), "\r\n", "\n")
+	}
+	return string(resp.Body)
+}
+
+func headersContent(resp *request.Response) string {
+	var lines []string
+
+	keys := make([]string, 0, len(resp.Header))
+	for k := range resp.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range resp.Header[k] {
+			lines = append(lines, headerKeyStyle.Render(k+":")+" "+v)
+		}
+	}
+
+	lines = append(lines, "", headerKeyStyle.Render("Connection"), "Protocol: "+resp.Proto)
+	if state := resp.TLS; state != nil {
+		lines = append(lines,
+			"TLS: "+tls.VersionName(state.Version),
+			"Cipher suite: "+tls.CipherSuiteName(state.CipherSuite),
+		)
+		if state.NegotiatedProtocol != "" {
+			lines = append(lines, "ALPN: "+state.NegotiatedProtocol)
+		}
+		if len(state.PeerCertificates) > 0 {
+			cert := state.PeerCertificates[0]
+			lines = append(lines,
+				"Certificate: "+cert.Subject.String(),
+				"Issuer: "+cert.Issuer.String(),
+				"Valid until: "+cert.NotAfter.Format(time.RFC3339),
+			)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func cookiesContent(resp *request.Response) string {
+	cookies := resp.Cookies()
+	if len(cookies) == 0 {
+		return "No cookies"
+	}
+
+	var blocks []string
+	for _, c := range cookies {
+		lines := []string{headerKeyStyle.Render(c.Name) + " = " + c.Value}
+		if c.Domain != "" {
+			lines = append(lines, "  Domain: "+c.Domain)
+		}
+		if c.Path != "" {
+			lines = append(lines, "  Path: "+c.Path)
+		}
+		if !c.Expires.IsZero() {
+			lines = append(lines, "  Expires: "+c.Expires.Format(time.RFC1123))
+		}
+		if c.MaxAge != 0 {
+			lines = append(lines, fmt.Sprintf("  Max-Age: %d", c.MaxAge))
+		}
+
+		var flags []string
+		if c.Secure {
+			flags = append(flags, "Secure")
+		}
+		if c.HttpOnly {
+			flags = append(flags, "HttpOnly")
+		}
+		switch c.SameSite {
+		case http.SameSiteLaxMode:
+			flags = append(flags, "SameSite=Lax")
+		case http.SameSiteStrictMode:
+			flags = append(flags, "SameSite=Strict")
+		case http.SameSiteNoneMode:
+			flags = append(flags, "SameSite=None")
+		}
+		if len(flags) > 0 {
+			lines = append(lines, "  "+strings.Join(flags, ", "))
+		}
+
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
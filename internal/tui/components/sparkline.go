@@ -0,0 +1,49 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+var (
+	progressFullStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#49CC90"))
+	progressEmptyStyle = lipgloss.NewStyle().Faint(true)
+)
+
+// Sparkline renders the last width values as block characters scaled to the
+// largest of them, it is padded on the left if there are fewer values
+func Sparkline(values []float64, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+
+	var top float64
+	for _, v := range values {
+		top = max(top, v)
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", width-len(values)))
+	for _, v := range values {
+		i := 0
+		if top > 0 {
+			i = int(v / top * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[min(max(i, 0), len(sparkBlocks)-1)])
+	}
+	return b.String()
+}
+
+// ProgressBar renders a bar of width characters filled to ratio
+func ProgressBar(ratio float64, width int) string {
+	ratio = min(max(ratio, 0), 1)
+	full := int(ratio * float64(width))
+	return progressFullStyle.Render(strings.Repeat("█", full)) +
+		progressEmptyStyle.Render(strings.Repeat("░", max(0, width-full)))
+}
@@ -0,0 +1,26 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	tabStyle       = lipgloss.NewStyle().Padding(0, 1).Faint(true)
+	activeTabStyle = lipgloss.NewStyle().Padding(0, 1).Bold(true).Underline(true).
+			Foreground(lipgloss.AdaptiveColor{Light: "#04B575", Dark: "#04B575"})
+)
+
+// RenderTabs renders a row of tab titles with the active one highlighted
+func RenderTabs(titles []string, active int) string {
+	tabs := make([]string, len(titles))
+	for i, title := range titles {
+		if i == active {
+			tabs[i] = activeTabStyle.Render(title)
+		} else {
+			tabs[i] = tabStyle.Render(title)
+		}
+	}
+	return strings.Join(tabs, "│")
+}
@@ -0,0 +1,55 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/bata94/reqlab/internal/request"
+)
+
+// phaseStyles color the phases of request.Timing.Phases in order
+var phaseStyles = []lipgloss.Style{
+	lipgloss.NewStyle().Foreground(lipgloss.Color("#61AFFE")),
+	lipgloss.NewStyle().Foreground(lipgloss.Color("#FCA130")),
+	lipgloss.NewStyle().Foreground(lipgloss.Color("#9012FE")),
+	lipgloss.NewStyle().Foreground(lipgloss.Color("#49CC90")),
+	lipgloss.NewStyle().Foreground(lipgloss.Color("#F93E3E")),
+}
+
+var phaseShortNames = []string{"dns", "tcp", "tls", "wait", "recv"}
+
+// TimingBar renders the phases of a request as a single stacked bar of width
+// characters followed by a legend with their durations
+func TimingBar(t request.Timing, width int) string {
+	phases := t.Phases()
+
+	var bar strings.Builder
+	used := 0
+	if t.Total > 0 && width > 0 {
+		scale := float64(width) / float64(t.Total)
+		for i, p := range phases {
+			length := int(float64(p.Duration) * scale)
+			if p.Duration > 0 && length == 0 {
+				length = 1
+			}
+			length = min(length, width-used)
+			used += length
+			bar.WriteString(phaseStyles[i].Render(strings.Repeat("█", length)))
+		}
+	}
+	bar.WriteString(strings.Repeat(" ", max(0, width-used)))
+
+	var legend []string
+	for i, p := range phases {
+		if p.Duration == 0 {
+			continue
+		}
+		legend = append(legend, phaseStyles[i].Render(phaseShortNames[i])+" "+request.FormatDuration(p.Duration))
+	}
+	if t.ConnReused {
+		legend = append(legend, "reused")
+	}
+
+	return bar.String() + " " + strings.Join(legend, " · ")
+}
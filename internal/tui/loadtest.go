@@ -0,0 +1,473 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/bata94/reqlab/internal/loadtest"
+	"github.com/bata94/reqlab/internal/request"
+	"github.com/bata94/reqlab/internal/tui/components"
+)
+
+// LoadtestConfig is the attack shown by LoadtestView
+type LoadtestConfig struct {
+	Attacker *loadtest.Attacker
+	Targeter loadtest.Targeter
+	Title    string
+	// OnResult is called with every result, e.g. to write it. An error
+	// aborts the attack.
+	OnResult func(*loadtest.Result) error
+}
+
+// LoadtestView runs the attack and shows it live until it is done and the
+// dashboard is closed. Quitting early aborts the attack, LoadtestView returns
+// once all results are handled. The main TUI shows the same dashboard for
+// load tests started there.
+func LoadtestView(cfg LoadtestConfig) error {
+	d := startDashboard(cfg, false)
+	_, err := tea.NewProgram(loadtestModel{d}, tea.WithAltScreen()).Run()
+
+	d.stop()
+	if err != nil {
+		return err
+	}
+	return d.err
+}
+
+// loadtestModel shows a dashboard as the whole program
+type loadtestModel struct {
+	d *dashboard
+}
+
+func (m loadtestModel) Init() tea.Cmd {
+	return m.d.init()
+}
+
+func (m loadtestModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.d.setWidth(msg.Width)
+	case tea.KeyMsg:
+		if key.Matches(msg, m.d.keys.Quit) {
+			return m, tea.Quit
+		}
+	}
+	return m, m.d.update(msg)
+}
+
+func (m loadtestModel) View() string {
+	return m.d.view()
+}
+
+// liveSeconds is the history kept for the sparklines
+const liveSeconds = 300
+
+// liveErrors is the number of errors shown in the tail
+const liveErrors = 6
+
+// liveStats aggregates the results of a running attack, per second for the
+// sparklines and in total
+type liveStats struct {
+	mu      sync.Mutex
+	start   time.Time
+	total   loadtest.Metrics
+	seconds [liveSeconds]liveSecond
+	errors  []liveError
+}
+
+// liveSecond holds the results received in second sec of the dashboard
+type liveSecond struct {
+	sec   int64
+	count uint64
+	hist  loadtest.Histogram
+}
+
+type liveError struct {
+	at     time.Duration
+	target string
+	msg    string
+}
+
+func (s *liveStats) add(r *loadtest.Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.total.Add(r)
+
+	at := time.Since(s.start)
+	sec := int64(at / time.Second)
+	slot := &s.seconds[sec%liveSeconds]
+	if slot.sec != sec || slot.count == 0 {
+		*slot = liveSecond{sec: sec}
+	}
+	slot.count++
+	slot.hist.Record(r.Latency)
+
+	if !r.Success() {
+		s.errors = append(s.errors, liveError{at: at, target: r.Target, msg: r.Error})
+		if len(s.errors) > liveErrors {
+			s.errors = s.errors[len(s.errors)-liveErrors:]
+		}
+	}
+}
+
+// series returns fn of the last n complete seconds, oldest first. Seconds
+// without results are zero.
+func (s *liveStats) series(n int, fn func(*liveSecond) float64) []float64 {
+	now := int64(time.Since(s.start) / time.Second)
+	n = min(n, liveSeconds-1, int(now))
+
+	values := make([]float64, n)
+	for i := range values {
+		sec := now - int64(n) + int64(i)
+		if slot := &s.seconds[sec%liveSeconds]; slot.sec == sec && slot.count > 0 {
+			values[i] = fn(slot)
+		}
+	}
+	return values
+}
+
+type loadtestKeyMap struct {
+	Pause key.Binding
+	Abort key.Binding
+	Back  key.Binding
+	Quit  key.Binding
+}
+
+func newLoadtestKeyMap() *loadtestKeyMap {
+	return &loadtestKeyMap{
+		Pause: key.NewBinding(
+			key.WithKeys("p", " "),
+			key.WithHelp("p", "pause/resume"),
+		),
+		Abort: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "abort"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "back to the requests"),
+		),
+		Quit: key.NewBinding(
+			key.WithKeys("q", "ctrl+c"),
+			key.WithHelp("q", "quit"),
+		),
+	}
+}
+
+func (k loadtestKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Pause, k.Abort, k.Back, k.Quit}
+}
+
+func (k loadtestKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// loadtestTickMsg redraws dashboard id
+type loadtestTickMsg struct {
+	id int
+}
+
+// attackDoneMsg is sent once all results of the attack of dashboard id are
+// handled
+type attackDoneMsg struct {
+	id int
+}
+
+const loadtestRefresh = 250 * time.Millisecond
+
+// dashboard runs an attack and shows it live. It is the load test view of
+// the main TUI and all of LoadtestView.
+type dashboard struct {
+	id       int
+	cfg      LoadtestConfig
+	stats    *liveStats
+	cancel   context.CancelFunc
+	done     chan struct{}
+	err      error // Of OnResult, set once done is closed
+	finished bool
+	aborted  bool
+	keys     *loadtestKeyMap
+	help     help.Model
+	width    int
+}
+
+// lastDashboardID tells the messages of dashboards apart
+var lastDashboardID int
+
+// startDashboard starts the attack of cfg, embedded dashboards are left with
+// esc instead of quitting
+func startDashboard(cfg LoadtestConfig, embedded bool) *dashboard {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	lastDashboardID++
+	d := &dashboard{
+		id:     lastDashboardID,
+		cfg:    cfg,
+		stats:  &liveStats{start: time.Now()},
+		cancel: cancel,
+		done:   make(chan struct{}),
+		keys:   newLoadtestKeyMap(),
+		help:   help.New(),
+	}
+	d.keys.Back.SetEnabled(embedded)
+
+	results := cfg.Attacker.Attack(ctx, cfg.Targeter)
+	go func() {
+		defer close(d.done)
+		for res := range results {
+			d.stats.add(res)
+			if cfg.OnResult != nil && d.err == nil {
+				if d.err = cfg.OnResult(res); d.err != nil {
+					cancel()
+				}
+			}
+		}
+	}()
+
+	return d
+}
+
+// stop aborts the attack and waits until all results are handled
+func (d *dashboard) stop() {
+	d.cfg.Attacker.Resume()
+	d.cancel()
+	<-d.done
+}
+
+func (d *dashboard) setWidth(width int) {
+	d.width = width
+	d.help.Width = width
+}
+
+var (
+	ltTitleStyle = lipgloss.NewStyle().Bold(true)
+	ltLabelStyle = lipgloss.NewStyle().Faint(true)
+	ltErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#F93E3E"))
+	ltStateStyle = map[string]lipgloss.Style{
+		"running": lipgloss.NewStyle().Foreground(lipgloss.Color("#49CC90")),
+		"paused":  lipgloss.NewStyle().Foreground(lipgloss.Color("#FCA130")),
+		"aborted": lipgloss.NewStyle().Foreground(lipgloss.Color("#F93E3E")),
+		"done":    lipgloss.NewStyle().Foreground(lipgloss.Color("#61AFFE")),
+	}
+)
+
+func (d *dashboard) init() tea.Cmd {
+	return tea.Batch(d.tick(), d.waitDone())
+}
+
+func (d *dashboard) tick() tea.Cmd {
+	id := d.id
+	return tea.Tick(loadtestRefresh, func(time.Time) tea.Msg {
+		return loadtestTickMsg{id: id}
+	})
+}
+
+func (d *dashboard) waitDone() tea.Cmd {
+	id, done := d.id, d.done
+	return func() tea.Msg {
+		<-done
+		return attackDoneMsg{id: id}
+	}
+}
+
+// update handles the ticks and keys of the dashboard, quitting and going
+// back are up to the program showing it
+func (d *dashboard) update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case loadtestTickMsg:
+		if msg.id != d.id || d.finished {
+			return nil
+		}
+		return d.tick()
+	case attackDoneMsg:
+		if msg.id == d.id {
+			d.finished = true
+		}
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, d.keys.Abort):
+			if !d.finished {
+				d.aborted = true
+				d.cfg.Attacker.Resume()
+				d.cancel()
+			}
+		case key.Matches(msg, d.keys.Pause):
+			if d.finished || d.aborted {
+				break
+			}
+			if d.cfg.Attacker.Paused() {
+				d.cfg.Attacker.Resume()
+			} else {
+				d.cfg.Attacker.Pause()
+			}
+		}
+	}
+	return nil
+}
+
+// summary describes the outcome of the attack in a line
+func (d *dashboard) summary() string {
+	d.stats.mu.Lock()
+	total := d.stats.total
+	total.Close()
+	d.stats.mu.Unlock()
+
+	return fmt.Sprintf("Load test %s: %d requests, %.2f%% success, p95 %s",
+		d.state(), total.Requests, total.Success*100,
+		request.FormatDuration(total.Latencies.P95))
+}
+
+func (d *dashboard) state() string {
+	switch {
+	case d.aborted:
+		return "aborted"
+	case d.finished:
+		return "done"
+	case d.cfg.Attacker.Paused():
+		return "paused"
+	}
+	return "running"
+}
+
+func (d *dashboard) view() string {
+	s := d.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := s.total
+	total.Close()
+
+	opts := d.cfg.Attacker.Options()
+	elapsed := d.cfg.Attacker.Elapsed()
+	width := max(d.width, 60)
+	sparkWidth := width - 24
+
+	var lines []string
+
+	state := d.state()
+	lines = append(lines, fmt.Sprintf("%s  %s  %s",
+		ltTitleStyle.Render("Load test "+d.cfg.Title),
+		ltStateStyle[state].Render("● "+state),
+		formatElapsed(elapsed),
+	))
+
+	// Progress towards the duration or number of requests, if there is one
+	switch {
+	case opts.Duration > 0:
+		ratio := float64(elapsed) / float64(opts.Duration)
+		lines = append(lines, components.ProgressBar(ratio, width-20)+
+			fmt.Sprintf(" %3.f%% of %s", min(ratio, 1)*100, opts.Duration))
+	case opts.Requests > 0:
+		ratio := float64(total.Requests) / float64(opts.Requests)
+		lines = append(lines, components.ProgressBar(ratio, width-20)+
+			fmt.Sprintf(" %3.f%% of %d", min(ratio, 1)*100, opts.Requests))
+	}
+	lines = append(lines, "")
+
+	rps := s.series(sparkWidth, func(sec *liveSecond) float64 { return float64(sec.count) })
+	current := 0.0
+	if len(rps) > 0 {
+		current = rps[len(rps)-1]
+	}
+	target := "max"
+	if opts.Pacer != nil {
+		target = fmt.Sprintf("%.1f", opts.Pacer.RateAt(elapsed))
+	}
+	lines = append(lines,
+		fmt.Sprintf("%s %.1f/s of %s/s  %s %d",
+			ltLabelStyle.Render("RPS"), current, target,
+			ltLabelStyle.Render("workers"), d.cfg.Attacker.Workers()),
+		fmt.Sprintf("%-22s %s", "", components.Sparkline(rps, sparkWidth)),
+	)
+
+	for _, q := range []struct {
+		name string
+		q    float64
+	}{{"p50", 0.5}, {"p95", 0.95}, {"p99", 0.99}} {
+		series := s.series(sparkWidth, func(sec *liveSecond) float64 {
+			return float64(sec.hist.Quantile(q.q))
+		})
+		last := 0.0
+		if len(series) > 0 {
+			last = series[len(series)-1]
+		}
+		lines = append(lines, fmt.Sprintf("%s %-18s %s",
+			ltLabelStyle.Render(q.name), request.FormatDuration(time.Duration(last)),
+			components.Sparkline(series, sparkWidth)))
+	}
+	lines = append(lines, "")
+
+	lines = append(lines, fmt.Sprintf("%s %d  %s %.2f%%  %s %s  %s %s",
+		ltLabelStyle.Render("requests"), total.Requests,
+		ltLabelStyle.Render("success"), total.Success*100,
+		ltLabelStyle.Render("in"), request.FormatSize(int(total.BytesIn.Total)),
+		ltLabelStyle.Render("out"), request.FormatSize(int(total.BytesOut.Total)),
+	))
+	for _, l := range []struct {
+		label string
+		m     loadtest.LatencyMetrics
+	}{{"latency ", total.Latencies}, {"response", total.ResponseTimes}} {
+		lines = append(lines, ltLabelStyle.Render(l.label)+fmt.Sprintf(" p50 %s  p95 %s  p99 %s  max %s",
+			request.FormatDuration(l.m.P50),
+			request.FormatDuration(l.m.P95),
+			request.FormatDuration(l.m.P99),
+			request.FormatDuration(l.m.Max),
+		))
+	}
+	if total.Late > 0 {
+		lines = append(lines, ltErrorStyle.Render(fmt.Sprintf("%d requests sent late, up to %s behind schedule",
+			total.Late, request.FormatDuration(total.MaxDelay))))
+	}
+
+	codes := make([]string, 0, len(total.StatusCodes))
+	for code := range total.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	var counters []string
+	for _, code := range codes {
+		status := components.StatusBadge(atoi(code), code)
+		counters = append(counters, fmt.Sprintf("%s %d", status, total.StatusCodes[code]))
+	}
+	lines = append(lines, ltLabelStyle.Render("status  ")+" "+strings.Join(counters, "  "), "")
+
+	lines = append(lines, ltLabelStyle.Render("errors"))
+	if len(s.errors) == 0 {
+		lines = append(lines, "  none")
+	}
+	for _, e := range s.errors {
+		line := fmt.Sprintf("  %s %s %s", formatElapsed(e.at), e.target, e.msg)
+		lines = append(lines, ltErrorStyle.Render(truncate(line, width)))
+	}
+	lines = append(lines, "", d.help.View(d.keys))
+
+	return strings.Join(lines, "\n")
+}
+
+// formatElapsed formats d as minutes and seconds
+func formatElapsed(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	return fmt.Sprintf("%02d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// truncate cuts s to width cells, ending in an ellipsis if it is cut
+func truncate(s string, width int) string {
+	return ansi.Truncate(s, width, "…")
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -1,9 +1,9 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 	"time"
 
@@ -11,17 +11,27 @@ import (
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/bata94/reqlab/internal/filter"
+	"github.com/bata94/reqlab/internal/format"
+	"github.com/bata94/reqlab/internal/loadtest"
+	"github.com/bata94/reqlab/internal/request"
 	"github.com/bata94/reqlab/internal/tui/components"
+	"github.com/bata94/reqlab/pkgs/apiview"
+	"github.com/bata94/reqlab/pkgs/apiview/openapi"
 	// "github.com/bata94/reqlab/internal/tui/views"
 )
 
 const useHighPerformanceRenderer = false
 
+// editorHeight is the height of the request editor including its tabs
+const editorHeight = 12
+
 var (
 	titleStyle = func() lipgloss.Style {
 		b := lipgloss.RoundedBorder()
@@ -35,24 +45,67 @@ var (
 		return titleStyle.BorderStyle(b)
 	}()
 
+	filterHintStyle  = lipgloss.NewStyle().Faint(true)
+	filterErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#F93E3E"))
+
 	statusMessageStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.AdaptiveColor{Light: "#04B575", Dark: "#04B575"}).
 				Render
 )
 
-func MainView() {
+// MainView runs the TUI, doc is the spec whose endpoints are listed and may
+// be nil
+func MainView(doc *openapi.OpenAPI) error {
 	log.Info("Loading TUI ...")
 
-	p := tea.NewProgram(model{ready: false}, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		log.Fatalf("There's been an error: %v", err)
+	m := model{
+		ready:    false,
+		title:    "Endpoints",
+		tagIndex: -1,
+		pretty:   true,
+		spinner:  spinner.New(spinner.WithSpinner(spinner.Dot)),
+		inFlight: map[int]*inFlight{},
+	}
+	if doc != nil {
+		endpoints, err := apiview.FromOpenAPI(doc)
+		if err != nil {
+			return fmt.Errorf("reading the endpoints of the spec: %w", err)
+		}
+
+		var tagOrder []string
+		for _, tag := range doc.Tags {
+			tagOrder = append(tagOrder, tag.Name)
+		}
+
+		// Requests can still be edited to a full URL, the spec stays usable
+		for _, e := range endpoints {
+			if err := e.CheckServer(); err != nil {
+				m.status = "Relative servers, edit the URL before sending"
+				break
+			}
+		}
+
+		m.items = components.EndpointItems(endpoints, tagOrder)
+		m.tags = components.Tags(endpoints, tagOrder)
+		if doc.Info.Title != "" {
+			m.title = doc.Info.Title
+		}
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	final, err := p.Run()
+	if m, ok := final.(model); ok && m.loadtest != nil {
+		m.loadtest.stop()
 	}
+	return err
 }
 
-type custResp struct {
-	resp     *http.Response
-	Body     []byte
-	Duration time.Duration
+// inFlight is a request that has been sent but not answered yet
+type inFlight struct {
+	id     int
+	req    apiview.Request
+	start  time.Time
+	cancel context.CancelFunc
 }
 
 type model struct {
@@ -62,20 +115,91 @@ type model struct {
 	listDelegateKeys *components.DelegateKeyMap
 	url              textinput.Model
 	viewport         viewport.Model
-	resp             custResp
+	resp             *request.Response
+	spinner          spinner.Model
+	inFlight         map[int]*inFlight
+	lastID           int // ID of the last request sent
+	shownID          int // ID of the request whose response is shown
+	status           string
+	editor           components.RequestEditor
+	editorFocused    bool
+	respErr          error // Error of the shown request, if it failed
+	respTab          int
+	respFocused      bool
+	pretty           bool   // Show the body formatted instead of raw
+	prettyBody       string // Formatted body of the response prettyFor
+	prettyFor        int
+	formattingFor    int  // Response that is formatted in the background
+	treeMode         bool // Show JSON bodies as a tree instead of text
+	tree             components.JSONTree
+	treeFor          int // Response the tree was built from
+	filter           textinput.Model
+	filterOut        string // Highlighted result of the filter for filterFor
+	filterErr        error
+	filterFor        int
+	filterSeq        int // Only the result of the latest evaluation is shown
+	filterCancel     context.CancelFunc
+
+	title    string
+	items    []list.Item // All endpoints, the list may only show one tag
+	tags     []string
+	tagIndex int // Index of the shown tag, -1 shows all
+
+	loadtest     *dashboard      // Last load test started, nil if there was none
+	showLoadtest bool            // The dashboard replaces the requests
+	ltInput      textinput.Model // Rate and duration of the next load test
+	width        int
 }
 
-type respMsg custResp
-type errorMsg error
+// filterTimeout stops filters that take too long, e.g. endless loops
+const filterTimeout = 5 * time.Second
+
+// filteredMsg is sent when evaluation seq of the filter on response id is
+// done
+type filteredMsg struct {
+	seq int
+	id  int
+	out string
+	err error
+}
+
+// formattedMsg is sent when the body of response id has been formatted
+type formattedMsg struct {
+	id   int
+	body string
+}
+
+// respMsg is sent when request id is done, err is set if it failed
+type respMsg struct {
+	id   int
+	resp *request.Response
+	err  error
+}
 
 func (m model) Init() tea.Cmd {
 	return nil
 }
 
 func (m model) headerView() string {
-	title := titleStyle.Render("Response Body:")
-	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title)))
-	return lipgloss.JoinHorizontal(lipgloss.Center, title, line)
+	title := titleStyle.Render("Response")
+
+	// The summary of the shown response goes between the title and the line
+	summary := ""
+	if m.resp != nil {
+		summary = " " + components.ResponseSummary(m.resp) + " "
+	}
+
+	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title)-lipgloss.Width(summary)))
+	return lipgloss.JoinHorizontal(lipgloss.Center, title, summary, line)
+}
+
+// tabsView shows the tabs of the response pane and the timing bar
+func (m model) tabsView() string {
+	tabs := components.RenderTabs(components.ResponseTabs, m.respTab)
+	if m.resp == nil {
+		return tabs
+	}
+	return tabs + "  " + components.TimingBar(m.resp.Timing, 20)
 }
 
 func (m model) footerView() string {
@@ -90,22 +214,141 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds []tea.Cmd
 	)
 
-	if m.url.Focused() {
+	if m.ltInput.Focused() {
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
 			switch msg.String() {
 			case "esc":
+				m.ltInput.Blur()
+				return m, nil
+			case "enter":
+				m.ltInput.Blur()
+				return m, m.startLoadtest()
+			}
+			m.ltInput, cmd = m.ltInput.Update(msg)
+			return m, cmd
+		}
+	}
+
+	if m.showLoadtest {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch {
+			case key.Matches(msg, m.loadtest.keys.Quit):
 				return m, tea.Quit
+			case key.Matches(msg, m.loadtest.keys.Back):
+				m.showLoadtest = false
+				return m, nil
+			}
+			return m, m.loadtest.update(msg)
+		}
+	}
+
+	if m.url.Focused() {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "esc":
+				m.url.Blur()
+				return m, nil
 			case "enter":
-				return m, sendRequest(m.url.Value())
+				return m, m.send(m.request())
+			case "ctrl+x":
+				return m, m.cancelLatest()
 			}
 			m.url, cmd = m.url.Update(msg)
 			return m, cmd
 		}
 	}
 
+	if m.filter.Focused() {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "enter":
+				m.filter.Blur()
+				return m, nil
+			case "esc":
+				m.filter.Blur()
+				m.filter.SetValue("")
+				return m, m.runFilter()
+			}
+
+			// Evaluate the filter as it is typed
+			before := m.filter.Value()
+			m.filter, cmd = m.filter.Update(msg)
+			if m.filter.Value() != before {
+				cmd = tea.Batch(cmd, m.runFilter())
+			}
+			return m, cmd
+		}
+	}
+
+	if m.respFocused {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			if m.showTree() && m.tree.Editing() {
+				m.tree, cmd = m.tree.Update(msg)
+				return m, cmd
+			}
+
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "ctrl+x":
+				return m, m.cancelLatest()
+			case "esc":
+				m.respFocused = false
+				return m, nil
+			case "tab":
+				m.cycleResponseTab(1)
+				return m, nil
+			case "shift+tab":
+				m.cycleResponseTab(-1)
+				return m, nil
+			case "p":
+				return m, m.togglePretty()
+			case "v":
+				m.toggleTree()
+				return m, nil
+			case "f":
+				return m, m.focusFilter()
+			}
+			if m.showTree() {
+				m.tree, cmd = m.tree.Update(msg)
+				return m, cmd
+			}
+			m.viewport, cmd = m.viewport.Update(msg)
+			return m, cmd
+		}
+	}
+
+	if m.editorFocused {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "ctrl+x":
+				return m, m.cancelLatest()
+			case "esc":
+				if !m.editor.Editing() {
+					m.editorFocused = false
+					return m, nil
+				}
+			}
+			m.editor, cmd = m.editor.Update(msg)
+			return m, cmd
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		if m.loadtest != nil {
+			m.loadtest.setWidth(msg.Width)
+		}
+
 		headerHeight := lipgloss.Height(m.headerView())
 		footerHeight := lipgloss.Height(m.footerView())
 		verticalMarginHeight := headerHeight + footerHeight
@@ -116,31 +359,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				listKeys     = components.NewListKeyMap()
 			)
 
-			// Make initial list of items
-			items := []list.Item{
-				components.Item{
-					Title:       "test 1",
-					Description: "test 1 description",
-				},
-				components.Item{
-					Title:       "test 2",
-					Description: "test 2 description",
-				},
-				components.Item{
-					Title:       "test 3",
-					Description: "test 3 description",
-				},
-			}
-
 			// Setup list
 			delegate := components.NewItemDelegate(delegateKeys)
-			m.list = list.New(items, delegate, msg.Width/3, msg.Height)
-			m.list.Title = "Groceries"
+			m.list = list.New(m.items, delegate, msg.Width/3, msg.Height)
+			m.list.Title = m.title
 			m.list.Styles.Title = titleStyle
+			m.list.AdditionalShortHelpKeys = func() []key.Binding {
+				return []key.Binding{listKeys.CycleTag, listKeys.LoadTest}
+			}
 			m.list.AdditionalFullHelpKeys = func() []key.Binding {
 				return []key.Binding{
-					listKeys.ToggleSpinner,
-					listKeys.InsertItem,
+					listKeys.CycleTag,
+					listKeys.LoadTest,
 					listKeys.ToggleTitleBar,
 					listKeys.ToggleStatusBar,
 					listKeys.TogglePagination,
@@ -157,12 +387,25 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.url.CharLimit = 2048
 			m.url.Width = 60
 
+			m.filter = textinput.New()
+			m.filter.Prompt = "jq> "
+			m.filter.Placeholder = ".items[] | .id"
+			m.filter.Width = msg.Width - msg.Width/3 - 10
+
+			m.ltInput = textinput.New()
+			m.ltInput.Prompt = "load test> "
+			m.ltInput.Placeholder = "10/s 30s"
+			m.ltInput.Width = msg.Width - msg.Width/3 - 16
+
+			m.editor = components.NewRequestEditor()
+			m.editor.SetSize(msg.Width-msg.Width/3, editorHeight)
+
 			// Since this program is using the full size of the viewport we
 			// need to wait until we've received the window dimensions before
 			// we can initialize the viewport. The initial dimensions come in
 			// quickly, though asynchronously, which is why we wait for them
 			// here.
-			m.viewport = viewport.New(msg.Width, msg.Height-verticalMarginHeight-lipgloss.Height(m.url.View())-lipgloss.Height("Placeholder"))
+			m.viewport = viewport.New(msg.Width-msg.Width/3, msg.Height-verticalMarginHeight-lipgloss.Height(m.url.View())-lipgloss.Height("Placeholder")-editorHeight-3)
 			m.viewport.YPosition = headerHeight
 			m.viewport.HighPerformanceRendering = useHighPerformanceRenderer
 			m.viewport.SetContent("No Data")
@@ -174,8 +417,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Render the viewport one line below the header.
 			m.viewport.YPosition = headerHeight + 1
 		} else {
-			m.viewport.Width = msg.Width
-			m.viewport.Height = msg.Height - verticalMarginHeight
+			m.viewport.Width = msg.Width - msg.Width/3
+			m.viewport.Height = msg.Height - verticalMarginHeight - lipgloss.Height(m.url.View()) - lipgloss.Height("Placeholder") - editorHeight - 3
+			m.editor.SetSize(msg.Width-msg.Width/3, editorHeight)
+			m.tree.SetSize(m.viewport.Width, m.viewport.Height)
+			m.setResponseContent()
 		}
 
 		if useHighPerformanceRenderer {
@@ -192,10 +438,6 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		switch {
-		case key.Matches(msg, m.listKeys.ToggleSpinner):
-			cmd := m.list.ToggleSpinner()
-			return m, cmd
-
 		case key.Matches(msg, m.listKeys.ToggleTitleBar):
 			v := !m.list.ShowTitle()
 			m.list.SetShowTitle(v)
@@ -215,16 +457,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.list.SetShowHelp(!m.list.ShowHelp())
 			return m, nil
 
-		case key.Matches(msg, m.listKeys.InsertItem):
-			m.listDelegateKeys.Remove.SetEnabled(true)
-			newId := len(m.list.Items()) + 1
-			newItem := components.Item{
-				Title:       fmt.Sprintf("test %d", newId),
-				Description: fmt.Sprintf("test %d description", newId),
+		case key.Matches(msg, m.listKeys.CycleTag):
+			return m, m.cycleTag()
+
+		case key.Matches(msg, m.listKeys.LoadTest):
+			// Show the running load test, or ask for the next one
+			if m.loadtest != nil && !m.loadtest.finished {
+				m.showLoadtest = true
+				return m, nil
+			}
+			return m, m.ltInput.Focus()
+
+		case key.Matches(msg, m.listDelegateKeys.Choose):
+			// Let the list show its status message as well
+			if i, ok := m.list.SelectedItem().(components.Item); ok && i.Endpoint != nil {
+				req := apiview.NewRequest(*i.Endpoint)
+				m.url.SetValue(req.URL)
+				m.editor.SetRequest(req)
 			}
-			insCmd := m.list.InsertItem(0, newItem)
-			statusCmd := m.list.NewStatusMessage(statusMessageStyle("Added " + newItem.Title))
-			return m, tea.Batch(insCmd, statusCmd)
 		}
 		switch msg.String() {
 		case "ctrl+c", "q":
@@ -234,24 +484,106 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "u":
 			m.url.Focus()
 			return m, nil
+		case "e":
+			m.editorFocused = true
+			return m, nil
+		case "r":
+			m.respFocused = true
+			return m, nil
+		case "]":
+			m.cycleResponseTab(1)
+			return m, nil
+		case "[":
+			m.cycleResponseTab(-1)
+			return m, nil
+		case "p":
+			return m, m.togglePretty()
+		case "v":
+			m.toggleTree()
+			return m, nil
+		case "f":
+			return m, m.focusFilter()
 		case "s":
-			url := m.url.Value()
-			if url == "" {
-				url = m.url.Placeholder
-			}
-			return m, sendRequest(url)
+			return m, m.send(m.request())
+		case "ctrl+x":
+			return m, m.cancelLatest()
 		}
-	case errorMsg:
-		m.viewport.SetContent(msg.Error())
-		return m, nil
+	case spinner.TickMsg:
+		// Stop ticking once nothing is in flight, send starts it again
+		if len(m.inFlight) == 0 {
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
 	case respMsg:
-		m.resp = custResp(msg)
-
-		bodyStr := fmt.Sprint(m.resp.Duration, "\n") + string(m.resp.Body)
-		log.Debug("bodyStr: ", bodyStr)
+		req, ok := m.inFlight[msg.id]
+		if !ok {
+			return m, nil
+		}
+		delete(m.inFlight, msg.id)
 
-		m.viewport.SetContent(bodyStr)
+		name := fmt.Sprintf("#%d %s %s", msg.id, strings.ToUpper(req.req.Method.String()), req.req.URL)
+		switch {
+		case errors.Is(msg.err, context.Canceled):
+			m.status = name + " cancelled"
+			return m, nil
+		case msg.err != nil:
+			m.status = name + " failed"
+		default:
+			m.status = fmt.Sprintf("%s %s in %s", name, msg.resp.Status, msg.resp.Duration.Round(time.Millisecond))
+		}
 
+		// A slow request finishing late does not replace a newer response
+		if msg.id < m.shownID {
+			return m, nil
+		}
+		m.shownID = msg.id
+
+		m.resp = msg.resp
+		m.respErr = msg.err
+		m.buildTree()
+		cmd = m.formatBody()
+		m.setResponseContent()
+		m.viewport.GotoTop()
+
+		return m, tea.Batch(cmd, m.runFilter())
+	case loadtestTickMsg:
+		if m.loadtest == nil {
+			return m, nil
+		}
+		return m, m.loadtest.update(msg)
+	case attackDoneMsg:
+		if m.loadtest == nil || msg.id != m.loadtest.id {
+			return m, nil
+		}
+		cmd = m.loadtest.update(msg)
+		m.status = m.loadtest.summary()
+		return m, cmd
+	case components.CopiedMsg:
+		m.tree, cmd = m.tree.Update(msg)
+		return m, cmd
+	case filteredMsg:
+		if msg.seq != m.filterSeq {
+			return m, nil
+		}
+		// Keep showing the last result while the filter is being typed
+		m.filterErr = msg.err
+		if msg.err == nil {
+			m.filterOut = components.Highlight(format.KindJSON, msg.out)
+			m.filterFor = msg.id
+			m.setResponseContent()
+		}
+		return m, nil
+	case formattedMsg:
+		if m.formattingFor == msg.id {
+			m.formattingFor = 0
+		}
+		if msg.id != m.shownID {
+			return m, nil
+		}
+		m.prettyBody = msg.body
+		m.prettyFor = msg.id
+		m.setResponseContent()
 		return m, nil
 	}
 
@@ -259,18 +591,76 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.list = newListModel
 	cmds = append(cmds, cmd)
 
+	// Keep the cursor of the editor's inputs blinking
+	if _, ok := msg.(tea.KeyMsg); !ok {
+		m.editor, cmd = m.editor.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
 	return m, tea.Batch(cmds...)
 }
 
+// request returns the request as set up in the URL input and the editor
+func (m model) request() apiview.Request {
+	url := m.url.Value()
+	if url == "" {
+		url = m.url.Placeholder
+	}
+	return m.editor.Request(url)
+}
+
+// cycleTag limits the list to the next tag, after the last one all
+// endpoints are shown again
+func (m *model) cycleTag() tea.Cmd {
+	if len(m.tags) == 0 {
+		return nil
+	}
+
+	m.tagIndex++
+	if m.tagIndex >= len(m.tags) {
+		m.tagIndex = -1
+	}
+
+	items := m.items
+	title := m.title
+	if m.tagIndex >= 0 {
+		tag := m.tags[m.tagIndex]
+		title = fmt.Sprintf("%s: %s", m.title, tag)
+
+		items = nil
+		for _, item := range m.items {
+			if i, ok := item.(components.Item); ok && i.Tag == tag {
+				items = append(items, item)
+			}
+		}
+	}
+
+	m.list.Title = title
+	m.list.ResetSelected()
+	return m.list.SetItems(items)
+}
+
 func (m model) View() string {
+	// The editor and panes are set up once the size of the window is known
+	if !m.ready {
+		return "\n  Initializing..."
+	}
+	if m.showLoadtest {
+		return m.loadtest.view()
+	}
+
 	var style = lipgloss.NewStyle()
 
 	reqView := lipgloss.JoinVertical(
 		lipgloss.Top,
 		"Request URL:",
-		m.url.View(),
+		lipgloss.JoinHorizontal(lipgloss.Center, components.MethodBadge(m.editor.Method), " ", m.url.View()),
+		m.editor.View(),
+		m.statusView(),
 		m.headerView(),
-		m.viewport.View(),
+		m.tabsView(),
+		m.filterView(),
+		m.bodyView(),
 		m.footerView(),
 	)
 	retView := lipgloss.JoinHorizontal(lipgloss.Left, m.list.View(), reqView)
@@ -278,33 +668,261 @@ func (m model) View() string {
 	return style.Render(retView)
 }
 
-func sendRequest(url string) tea.Cmd {
-	timeBeforeReq := time.Now()
-	log.Debug("Sending GET request to:", url)
-	resp, err := http.Get(url)
-	timeAfterReq := time.Now()
+// cycleResponseTab moves step tabs through the response pane
+func (m *model) cycleResponseTab(step int) {
+	n := len(components.ResponseTabs)
+	m.respTab = ((m.respTab+step)%n + n) % n
+	m.setResponseContent()
+	m.viewport.GotoTop()
+}
+
+// setResponseContent shows the active tab of the response in the viewport
+func (m *model) setResponseContent() {
+	switch {
+	case m.respErr != nil:
+		m.viewport.SetContent(m.respErr.Error())
+	case m.resp == nil:
+		m.viewport.SetContent("No Data")
+	case m.respTab == components.RespTabBody && m.filtering() && m.filterFor == m.shownID:
+		m.viewport.SetContent(m.filterOut)
+	case m.respTab == components.RespTabBody && m.pretty && m.prettyFor == m.shownID:
+		m.viewport.SetContent(m.prettyBody)
+	default:
+		m.viewport.SetContent(components.ResponseContent(m.resp, m.respTab, m.viewport.Width))
+	}
+}
+
+// toggleTree switches JSON bodies between the tree and text
+func (m *model) toggleTree() {
+	m.treeMode = !m.treeMode
+	m.buildTree()
+}
+
+// buildTree decodes the shown body into the tree if it is shown as one
+func (m *model) buildTree() {
+	if !m.treeMode || m.resp == nil || m.treeFor == m.shownID {
+		return
+	}
+	if format.KindOf(m.resp.ContentType()) != format.KindJSON {
+		return
+	}
+
+	tree, err := components.NewJSONTree(m.resp.Body)
 	if err != nil {
-		log.Error("Error sending GET request:", err)
-		return func() tea.Msg {
-			return errorMsg(err)
+		log.Debug("Body is no valid JSON: ", err)
+		return
+	}
+	tree.SetSize(m.viewport.Width, m.viewport.Height)
+	m.tree = tree
+	m.treeFor = m.shownID
+}
+
+// showTree reports whether the body tab shows the JSON tree
+func (m model) showTree() bool {
+	return m.treeMode && m.respTab == components.RespTabBody && m.resp != nil && m.treeFor == m.shownID && !m.filtering()
+}
+
+// filtering reports whether a filter is set for the body
+func (m model) filtering() bool {
+	return strings.TrimSpace(m.filter.Value()) != ""
+}
+
+// focusFilter starts editing the filter of the body
+func (m *model) focusFilter() tea.Cmd {
+	if m.respTab != components.RespTabBody {
+		m.respTab = components.RespTabBody
+		m.setResponseContent()
+	}
+	return m.filter.Focus()
+}
+
+// runFilter evaluates the filter against the shown body in the background,
+// an evaluation still running is cancelled
+func (m *model) runFilter() tea.Cmd {
+	if m.filterCancel != nil {
+		m.filterCancel()
+		m.filterCancel = nil
+	}
+	m.filterSeq++
+
+	if !m.filtering() || m.resp == nil {
+		m.filterOut = ""
+		m.filterErr = nil
+		m.filterFor = 0
+		m.setResponseContent()
+		return nil
+	}
+
+	seq, id, body, expr := m.filterSeq, m.shownID, m.resp.Body, m.filter.Value()
+	ctx, cancel := context.WithTimeout(context.Background(), filterTimeout)
+	m.filterCancel = cancel
+
+	return func() tea.Msg {
+		defer cancel()
+		out, err := filter.Apply(ctx, expr, body, filter.Options{})
+		return filteredMsg{seq: seq, id: id, out: out, err: err}
+	}
+}
+
+// filterView shows the filter input and its error, or a hint how to use the
+// response pane if there is no filter
+func (m model) filterView() string {
+	if !m.filter.Focused() && !m.filtering() {
+		return filterHintStyle.Render("r focus · f filter · v tree · p pretty/raw · [/] tabs")
+	}
+
+	view := m.filter.View()
+	if m.filterErr != nil {
+		view += " " + filterErrorStyle.Render(m.filterErr.Error())
+	}
+	return view
+}
+
+// bodyView shows the response in the viewport or the JSON tree
+func (m model) bodyView() string {
+	if m.showTree() {
+		return m.tree.View()
+	}
+	return m.viewport.View()
+}
+
+// togglePretty switches the body between formatted and raw
+func (m *model) togglePretty() tea.Cmd {
+	m.pretty = !m.pretty
+	cmd := m.formatBody()
+	m.setResponseContent()
+	return cmd
+}
+
+// formatBody formats the shown body if needed. Small bodies are formatted
+// right away, large ones in the background while the raw body is shown.
+func (m *model) formatBody() tea.Cmd {
+	if !m.pretty || m.resp == nil || m.prettyFor == m.shownID || m.formattingFor == m.shownID {
+		return nil
+	}
+
+	id, resp := m.shownID, m.resp
+	if len(resp.Body) < components.LazyFormatSize {
+		m.prettyBody = components.PrettyBody(resp)
+		m.prettyFor = id
+		return nil
+	}
+
+	m.formattingFor = id
+	return func() tea.Msg {
+		return formattedMsg{id: id, body: components.PrettyBody(resp)}
+	}
+}
+
+// send executes req in the background, it is tracked by its ID until the
+// response arrives or it is cancelled
+func (m *model) send(req apiview.Request) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m.lastID++
+	id := m.lastID
+	m.inFlight[id] = &inFlight{id: id, req: req, start: time.Now(), cancel: cancel}
+
+	cmds := []tea.Cmd{func() tea.Msg {
+		defer cancel()
+		resp, err := request.Execute(ctx, nil, req)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Error("Error sending request #", id, ": ", err)
+		}
+		return respMsg{id: id, resp: resp, err: err}
+	}}
+	if len(m.inFlight) == 1 {
+		cmds = append(cmds, m.spinner.Tick)
+	}
+	return tea.Batch(cmds...)
+}
+
+// cancelLatest cancels the most recently sent request still in flight
+func (m *model) cancelLatest() tea.Cmd {
+	var latest *inFlight
+	for _, r := range m.inFlight {
+		if latest == nil || r.id > latest.id {
+			latest = r
+		}
+	}
+	if latest != nil {
+		latest.cancel()
+	}
+	return nil
+}
+
+// statusView shows the requests in flight, or how the last one went, and
+// whether a load test is running
+func (m model) statusView() string {
+	if m.ltInput.Focused() {
+		return m.ltInput.View()
+	}
+
+	running := ""
+	if m.loadtest != nil && !m.loadtest.finished {
+		running = "load test running, L shows it"
+		if m.status != "" || len(m.inFlight) > 0 {
+			running = " • " + running
 		}
+		running = filterHintStyle.Render(running)
+	}
+	if len(m.inFlight) == 0 {
+		return statusMessageStyle(m.status) + running
 	}
-	defer resp.Body.Close()
 
-	requestDuration := timeAfterReq.Sub(timeBeforeReq)
-	log.Debug("Request duration:", requestDuration)
+	var latest *inFlight
+	for _, r := range m.inFlight {
+		if latest == nil || r.id > latest.id {
+			latest = r
+		}
+	}
 
-	log.Debug("Response status:", resp.Status)
+	status := fmt.Sprintf("%s #%d %s %s %s", m.spinner.View(), latest.id,
+		strings.ToUpper(latest.req.Method.String()), latest.req.URL,
+		time.Since(latest.start).Round(100*time.Millisecond))
+	if len(m.inFlight) > 1 {
+		status += fmt.Sprintf(" (+%d more)", len(m.inFlight)-1)
+	}
+	return status + " • ctrl+x cancel" + running
+}
+
+// startLoadtest load tests the request as set up at the rate and for the
+// duration typed into the load test input, without a duration until it is
+// aborted
+func (m *model) startLoadtest() tea.Cmd {
+	input := m.ltInput.Value()
+	if strings.TrimSpace(input) == "" {
+		input = m.ltInput.Placeholder
+	}
 
-	body, err := io.ReadAll(resp.Body)
+	fields := strings.Fields(input)
+	if len(fields) > 2 {
+		m.status = fmt.Sprintf("Invalid load test %q, expected a rate and duration like %s", input, m.ltInput.Placeholder)
+		return nil
+	}
+	rate, err := loadtest.ParseRate(fields[0])
 	if err != nil {
-		log.Error("Error sending GET request:", err)
-		return func() tea.Msg {
-			return errorMsg(err)
+		m.status = err.Error()
+		return nil
+	}
+	var duration time.Duration
+	if len(fields) > 1 {
+		if duration, err = time.ParseDuration(fields[1]); err != nil || duration < 0 {
+			m.status = fmt.Sprintf("Invalid load test duration %q", fields[1])
+			return nil
 		}
 	}
 
-	return func() tea.Msg {
-		return respMsg(custResp{resp: resp, Body: body, Duration: requestDuration})
+	if m.loadtest != nil {
+		m.loadtest.stop()
 	}
+	target := loadtest.NewTarget(m.request())
+	m.loadtest = startDashboard(LoadtestConfig{
+		Attacker: loadtest.NewAttacker(loadtest.AttackOptions{Pacer: rate, Duration: duration}),
+		Targeter: loadtest.NewStaticTargeter(target),
+		Title:    target.Name,
+	}, true)
+	m.loadtest.setWidth(m.width)
+	m.showLoadtest = true
+	return m.loadtest.init()
 }
@@ -3,8 +3,12 @@ Copyright © 2023 NAME HERE bastian.sievers@gmail.com
 */
 package main
 
-import "github.com/bata94/reqlab/cmd"
+import (
+	"os"
+
+	"github.com/bata94/reqlab/cmd"
+)
 
 func main() {
-	cmd.Execute()
+	os.Exit(cmd.Execute())
 }
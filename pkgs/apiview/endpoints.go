@@ -0,0 +1,305 @@
+package apiview
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bata94/reqlab/pkgs/apiview/openapi"
+)
+
+// FromOpenAPI builds the endpoints of every operation in doc. Refs are
+// resolved first, endpoints are sorted by path and method. Relative servers
+// are resolved against the location of doc if it is an http or https URL,
+// else they are kept and Endpoint.CheckServer reports them.
+func FromOpenAPI(doc *openapi.OpenAPI) ([]Endpoint, error) {
+	resolved, err := openapi.Resolve(doc)
+	if err != nil {
+		return nil, err
+	}
+	base := specURL(doc)
+
+	paths := make([]string, 0, len(resolved.Paths))
+	for path := range resolved.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var endpoints []Endpoint
+	for _, path := range paths {
+		item := resolved.Paths[path]
+		for _, method := range HTTPMethods {
+			op := Operation(item, method)
+			if op == nil {
+				continue
+			}
+			endpoints = append(endpoints, newEndpoint(resolved, base, path, method, item, op))
+		}
+	}
+
+	return endpoints, nil
+}
+
+// FindEndpoint returns the endpoint of the operation with the given ID
+func FindEndpoint(endpoints []Endpoint, operationID string) (Endpoint, bool) {
+	for _, e := range endpoints {
+		if e.OperationID == operationID {
+			return e, true
+		}
+	}
+	return Endpoint{}, false
+}
+
+// Operation returns the operation of item for method, nil if there is none
+func Operation(item openapi.PathItem, method HTTPMethod) *openapi.Operation {
+	switch method {
+	case GET:
+		return item.Get
+	case POST:
+		return item.Post
+	case PUT:
+		return item.Put
+	case DELETE:
+		return item.Delete
+	case PATCH:
+		return item.Patch
+	case HEAD:
+		return item.Head
+	case OPTIONS:
+		return item.Options
+	case TRACE:
+		return item.Trace
+	}
+	return nil
+}
+
+// specURL returns the URL doc was loaded from, nil if it was read from a
+// file or stdin
+func specURL(doc *openapi.OpenAPI) *url.URL {
+	u, err := url.Parse(doc.Location())
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil
+	}
+	return u
+}
+
+// ServerURL returns the URL of s with every variable set to its default
+func ServerURL(s openapi.Server) string {
+	url := s.URL
+	for name, v := range s.Variables {
+		url = strings.ReplaceAll(url, "{"+name+"}", v.Default)
+	}
+	return url
+}
+
+func newEndpoint(doc *openapi.OpenAPI, base *url.URL, path string, method HTTPMethod, item openapi.PathItem, op *openapi.Operation) Endpoint {
+	e := Endpoint{
+		Path:        path,
+		Method:      method,
+		OperationID: op.OperationID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Deprecated:  op.Deprecated,
+	}
+
+	if e.Summary == "" {
+		e.Summary = item.Summary
+	}
+	if e.Description == "" {
+		e.Description = item.Description
+	}
+
+	// The most specific server list wins, the spec defaults to "/"
+	servers := doc.Servers
+	if len(item.Servers) > 0 {
+		servers = item.Servers
+	}
+	if len(op.Servers) > 0 {
+		servers = op.Servers
+	}
+	for _, s := range servers {
+		e.Servers = append(e.Servers, ServerURL(s))
+	}
+	if len(e.Servers) == 0 {
+		e.Servers = []string{"/"}
+	}
+	if base != nil {
+		for i, server := range e.Servers {
+			if u, err := url.Parse(server); err == nil {
+				e.Servers[i] = base.ResolveReference(u).String()
+			}
+		}
+	}
+
+	e.Parameters = mergeParameters(item.Parameters, op.Parameters)
+	for _, p := range e.Parameters {
+		if p.In != "header" {
+			continue
+		}
+		e.Headers = append(e.Headers, Header{
+			Key:     p.Name,
+			Value:   ValueString(p.Example),
+			Enabled: p.Required,
+		})
+	}
+
+	if op.RequestBody != nil {
+		e.RequestBody = &RequestBody{
+			Description: op.RequestBody.Description,
+			Required:    op.RequestBody.Required,
+			MediaTypes:  mediaTypes(op.RequestBody.Content),
+		}
+	}
+
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		resp := op.Responses[code]
+		e.Responses = append(e.Responses, Response{
+			Status:      code,
+			Description: resp.Description,
+			MediaTypes:  mediaTypes(resp.Content),
+		})
+	}
+
+	return e
+}
+
+// mergeParameters applies the operation's parameters on top of the ones of
+// its path, a parameter is identified by its name and location
+func mergeParameters(pathParams []openapi.Parameter, opParams []openapi.Parameter) []Parameter {
+	var out []Parameter
+	index := map[string]int{}
+
+	for _, list := range [][]openapi.Parameter{pathParams, opParams} {
+		for _, p := range list {
+			if p.Name == "" {
+				// An unresolved ref, nothing we could send
+				continue
+			}
+
+			param := Parameter{
+				Name:        p.Name,
+				In:          p.In,
+				Description: p.Description,
+				Required:    p.Required || p.In == "path",
+				Deprecated:  p.Deprecated,
+				Schema:      p.Schema,
+				Example:     parameterExample(p),
+			}
+
+			key := p.In + ":" + p.Name
+			if i, ok := index[key]; ok {
+				out[i] = param
+				continue
+			}
+			index[key] = len(out)
+			out = append(out, param)
+		}
+	}
+
+	return out
+}
+
+func parameterExample(p openapi.Parameter) interface{} {
+	if v := openapi.DecodeValue(p.Example); v != nil {
+		return v
+	}
+	if ex, ok := firstExample(p.Examples); ok {
+		return ex
+	}
+	if p.Schema != nil {
+		return schemaExample(p.Schema)
+	}
+	return nil
+}
+
+func mediaTypes(content map[string]openapi.MediaType) []MediaType {
+	types := make([]string, 0, len(content))
+	for t := range content {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	out := make([]MediaType, 0, len(types))
+	for _, t := range types {
+		mt := content[t]
+
+		example := openapi.DecodeValue(mt.Example)
+		if example == nil {
+			if ex, ok := firstExample(mt.Examples); ok {
+				example = ex
+			} else if mt.Schema != nil {
+				example = schemaExample(mt.Schema)
+			}
+		}
+
+		out = append(out, MediaType{
+			ContentType: t,
+			Schema:      mt.Schema,
+			Example:     example,
+		})
+	}
+	return out
+}
+
+// firstExample picks the value of the alphabetically first named example
+func firstExample(examples map[string]openapi.Example) (interface{}, bool) {
+	names := make([]string, 0, len(examples))
+	for name, ex := range examples {
+		if openapi.DecodeValue(ex.Value) != nil {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, false
+	}
+	sort.Strings(names)
+	return openapi.DecodeValue(examples[names[0]].Value), true
+}
+
+// schemaExample returns the value a schema documents itself, if any
+func schemaExample(s *openapi.Schema) interface{} {
+	example, def, constant := openapi.DecodeValue(s.Example), openapi.DecodeValue(s.Default), openapi.DecodeValue(s.Const)
+	switch {
+	case example != nil:
+		return example
+	case len(s.Examples) > 0:
+		return s.Examples[0]
+	case def != nil:
+		return def
+	case constant != nil:
+		return constant
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	}
+	return nil
+}
+
+// ValueString renders an example value the way it would be sent in a path,
+// query or header, objects and arrays are encoded as JSON
+func ValueString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,194 @@
+package apiview
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// endpointsSpec has servers on all three levels and parameters on the path
+// and its operations
+const endpointsSpec = `
+openapi: 3.0.3
+info: {title: Users, version: "1"}
+servers:
+  - url: https://{region}.example.com/v1
+    variables:
+      region: {default: eu}
+paths:
+  /users/{id}:
+    servers:
+      - url: https://users.example.com
+    parameters:
+      - {name: id, in: path, schema: {type: integer}, example: 1}
+      - {name: verbose, in: query, schema: {type: boolean}}
+      - {name: X-Trace, in: header, required: true, example: abc}
+    get:
+      operationId: getUser
+      servers:
+        - url: https://get.example.com
+      parameters:
+        - {name: verbose, in: query, required: true, example: true}
+        - {name: fields, in: query, schema: {type: string, default: name}}
+    delete:
+      operationId: deleteUser
+      parameters:
+        - {name: X-Trace, in: header, example: def}
+        - {name: id, in: cookie, example: session}
+  /health:
+    get:
+      operationId: health
+`
+
+func loadEndpoints(t *testing.T, spec string) []Endpoint {
+	t.Helper()
+	doc, err := ParseSpec([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err := FromOpenAPI(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return endpoints
+}
+
+func endpoint(t *testing.T, endpoints []Endpoint, id string) Endpoint {
+	t.Helper()
+	e, ok := FindEndpoint(endpoints, id)
+	if !ok {
+		t.Fatalf("no endpoint %s", id)
+	}
+	return e
+}
+
+func TestMergeParameters(t *testing.T) {
+	endpoints := loadEndpoints(t, endpointsSpec)
+
+	type param struct {
+		Name, In string
+		Required bool
+		Example  interface{}
+	}
+	params := func(e Endpoint) []param {
+		var out []param
+		for _, p := range e.Parameters {
+			out = append(out, param{p.Name, p.In, p.Required, p.Example})
+		}
+		return out
+	}
+
+	tests := []struct {
+		id   string
+		want []param
+	}{
+		{
+			// The operation overrides verbose in place and adds fields, path
+			// params are always required
+			"getUser", []param{
+				{"id", "path", true, float64(1)},
+				{"verbose", "query", true, true},
+				{"X-Trace", "header", true, "abc"},
+				{"fields", "query", false, "name"},
+			},
+		},
+		{
+			// A parameter is identified by its name and location
+			"deleteUser", []param{
+				{"id", "path", true, float64(1)},
+				{"verbose", "query", false, nil},
+				{"X-Trace", "header", false, "def"},
+				{"id", "cookie", false, "session"},
+			},
+		},
+		{"health", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := params(endpoint(t, endpoints, tt.id)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+
+	wantHeaders := []Header{{Key: "X-Trace", Value: "def", Enabled: false}}
+	if got := endpoint(t, endpoints, "deleteUser").Headers; !reflect.DeepEqual(got, wantHeaders) {
+		t.Errorf("headers %+v, want %+v", got, wantHeaders)
+	}
+}
+
+func TestServerPrecedence(t *testing.T) {
+	endpoints := loadEndpoints(t, endpointsSpec)
+
+	tests := map[string]string{
+		"getUser":    "https://get.example.com/users/{id}",   // Of the operation
+		"deleteUser": "https://users.example.com/users/{id}", // Of the path item
+		"health":     "https://eu.example.com/v1/health",     // Of the document
+	}
+	for id, want := range tests {
+		e := endpoint(t, endpoints, id)
+		if got := e.URL(); got != want {
+			t.Errorf("%s: URL %s, want %s", id, got, want)
+		}
+		if err := e.CheckServer(); err != nil {
+			t.Errorf("%s: %v", id, err)
+		}
+	}
+}
+
+func TestRelativeServers(t *testing.T) {
+	const spec = `
+openapi: 3.1.0
+info: {title: Pets, version: "1"}
+servers:
+  - url: /api/v3
+paths:
+  /pets:
+    get: {operationId: listPets}
+    post:
+      operationId: createPet
+      servers:
+        - url: v2
+`
+	noServers := `
+openapi: 3.1.0
+info: {title: Pets, version: "1"}
+paths:
+  /pets:
+    get: {operationId: listPets}
+`
+
+	// Read from a file the servers can't be resolved
+	for _, e := range append(loadEndpoints(t, spec), loadEndpoints(t, noServers)...) {
+		if err := e.CheckServer(); !errors.Is(err, ErrRelativeServer) {
+			t.Errorf("%s %s: CheckServer = %v, want ErrRelativeServer", e.OperationID, e.URL(), err)
+		}
+	}
+
+	// Loaded from a URL they are resolved against it
+	tests := []struct {
+		spec, id, want string
+	}{
+		{spec, "listPets", "https://pets.example.com/api/v3/pets"},
+		{spec, "createPet", "https://pets.example.com/specs/v2/pets"},
+		{noServers, "listPets", "https://pets.example.com/pets"},
+	}
+	for _, tt := range tests {
+		doc, err := ParseSpec([]byte(tt.spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc.SetLocation("https://pets.example.com/specs/openapi.yaml")
+		endpoints, err := FromOpenAPI(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := endpoint(t, endpoints, tt.id)
+		if got := e.URL(); got != tt.want {
+			t.Errorf("%s: URL %s, want %s", tt.id, got, tt.want)
+		}
+		if err := e.CheckServer(); err != nil {
+			t.Errorf("%s: %v", tt.id, err)
+		}
+	}
+}
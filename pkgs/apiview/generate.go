@@ -0,0 +1,207 @@
+package apiview
+
+import (
+	"math"
+	"sort"
+
+	"github.com/bata94/reqlab/pkgs/apiview/openapi"
+)
+
+// maxGenerateDepth stops generating nested values, e.g. for recursive schemas
+const maxGenerateDepth = 6
+
+// GenerateExample returns a value matching s for when neither the parameter
+// nor the schema document one. Documented values of nested schemas are used,
+// anything else is made up from the type, format and bounds.
+func GenerateExample(s *openapi.Schema) interface{} {
+	return generate(s, 0)
+}
+
+func generate(s *openapi.Schema, depth int) interface{} {
+	if s == nil || depth > maxGenerateDepth {
+		return nil
+	}
+	if v := schemaExample(s); v != nil {
+		return v
+	}
+
+	switch {
+	case len(s.AllOf) > 0:
+		// Merge the objects, the first value that is no object wins
+		merged := map[string]interface{}{}
+		for _, sub := range s.AllOf {
+			v := generate(sub, depth+1)
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return v
+			}
+			for k, val := range obj {
+				merged[k] = val
+			}
+		}
+		return merged
+	case len(s.OneOf) > 0:
+		return generate(s.OneOf[0], depth+1)
+	case len(s.AnyOf) > 0:
+		return generate(s.AnyOf[0], depth+1)
+	}
+
+	switch {
+	case s.Type.Is("object") || (len(s.Type) == 0 && len(s.Properties) > 0):
+		return generateObject(s, depth)
+	case s.Type.Is("array"):
+		item := generate(s.Items, depth+1)
+		if item == nil {
+			return []interface{}{}
+		}
+		return []interface{}{item}
+	case s.Type.Is("integer"):
+		return generateNumber(s, true)
+	case s.Type.Is("number"):
+		return generateNumber(s, false)
+	case s.Type.Is("boolean"):
+		return true
+	case s.Type.Is("string"):
+		return generateString(s)
+	}
+	return nil
+}
+
+// generateObject fills the required properties, or all of them if none are
+// required
+func generateObject(s *openapi.Schema, depth int) map[string]interface{} {
+	names := s.Required
+	if len(names) == 0 {
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	obj := map[string]interface{}{}
+	for _, name := range names {
+		prop := s.Properties[name]
+		if prop != nil && prop.ReadOnly {
+			continue
+		}
+		if v := generate(prop, depth+1); v != nil {
+			obj[name] = v
+		}
+	}
+	return obj
+}
+
+// generateNumber picks the midpoint of the bounds of s, or a value next to
+// the one bound it has, and snaps it to multipleOf. Integers are rounded up
+// to the next one, or down if that leaves the bounds.
+func generateNumber(s *openapi.Schema, integer bool) interface{} {
+	b := schemaBounds(s)
+
+	var v float64
+	switch {
+	case b.hasMin && b.hasMax:
+		v = (b.min + b.max) / 2
+	case b.hasMin:
+		v = b.min
+		if b.minExclusive {
+			v++
+		}
+	case b.hasMax:
+		v = min(1, b.max)
+		if b.maxExclusive && v >= b.max {
+			v = b.max - 1
+		}
+	default:
+		v = 1
+	}
+
+	step := 0.0
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		step = *s.MultipleOf
+	} else if integer {
+		step = 1
+	}
+	if step > 0 {
+		if up := math.Ceil(v/step) * step; b.contains(up) {
+			v = up
+		} else {
+			v = math.Floor(v/step) * step
+		}
+	}
+	return v
+}
+
+// numberBounds are the bounds of a number schema
+type numberBounds struct {
+	min, max                   float64
+	hasMin, hasMax             bool
+	minExclusive, maxExclusive bool
+}
+
+// schemaBounds returns the bounds of s, the stricter one if a 3.1 schema has
+// both an inclusive and an exclusive bound
+func schemaBounds(s *openapi.Schema) numberBounds {
+	var b numberBounds
+	if s.Minimum != nil {
+		b.min, b.hasMin = *s.Minimum, true
+	}
+	if lo, ok := s.ExclusiveMinimumValue(); ok && (!b.hasMin || lo >= b.min) {
+		b.min, b.hasMin, b.minExclusive = lo, true, true
+	}
+	if s.Maximum != nil {
+		b.max, b.hasMax = *s.Maximum, true
+	}
+	if hi, ok := s.ExclusiveMaximumValue(); ok && (!b.hasMax || hi <= b.max) {
+		b.max, b.hasMax, b.maxExclusive = hi, true, true
+	}
+	return b
+}
+
+// contains reports whether v is within the bounds
+func (b numberBounds) contains(v float64) bool {
+	switch {
+	case b.hasMin && (v < b.min || b.minExclusive && v == b.min):
+		return false
+	case b.hasMax && (v > b.max || b.maxExclusive && v == b.max):
+		return false
+	}
+	return true
+}
+
+func generateString(s *openapi.Schema) string {
+	var v string
+	switch s.Format {
+	case "uuid":
+		v = "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "date":
+		v = "2024-01-01"
+	case "date-time":
+		v = "2024-01-01T00:00:00Z"
+	case "time":
+		v = "00:00:00"
+	case "email":
+		v = "user@example.com"
+	case "uri", "url":
+		v = "https://example.com"
+	case "hostname":
+		v = "example.com"
+	case "ipv4":
+		v = "192.0.2.1"
+	case "ipv6":
+		v = "2001:db8::1"
+	case "byte":
+		v = "c3RyaW5n"
+	default:
+		v = "string"
+	}
+
+	if s.MinLength != nil {
+		for len(v) < *s.MinLength {
+			v += "x"
+		}
+	}
+	if s.MaxLength != nil && len(v) > *s.MaxLength {
+		v = v[:*s.MaxLength]
+	}
+	return v
+}
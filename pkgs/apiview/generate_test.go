@@ -0,0 +1,83 @@
+package apiview
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/bata94/reqlab/pkgs/apiview/openapi"
+)
+
+// schema decodes a schema written as JSON
+func schema(t *testing.T, s string) *openapi.Schema {
+	t.Helper()
+	var out openapi.Schema
+	if err := json.Unmarshal([]byte(s), &out); err != nil {
+		t.Fatalf("schema %s: %v", s, err)
+	}
+	return &out
+}
+
+func TestGenerateNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   float64
+	}{
+		{"integer", `{"type": "integer"}`, 1},
+		{"number", `{"type": "number"}`, 1},
+		{"integer above a fractional minimum", `{"type": "integer", "minimum": 0.5}`, 1},
+		{"minimum", `{"type": "integer", "minimum": 10}`, 10},
+		{"exclusive minimum", `{"type": "integer", "exclusiveMinimum": 5}`, 6},
+		{"maximum below one", `{"type": "number", "maximum": -5}`, -5},
+		{"exclusive maximum of one", `{"type": "integer", "exclusiveMaximum": 1}`, 0},
+		{"midpoint", `{"type": "integer", "minimum": 10, "maximum": 20}`, 15},
+		{"midpoint rounded up", `{"type": "integer", "minimum": 10, "maximum": 11}`, 11},
+		{"exclusive bounds", `{"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1}`, 0.5},
+		{"exclusive bounds of 3.0", `{"type": "number", "minimum": 0, "exclusiveMinimum": true, "maximum": 1, "exclusiveMaximum": true}`, 0.5},
+		{"stricter bound of 3.1", `{"type": "integer", "minimum": 0, "exclusiveMinimum": 3}`, 4},
+		{"rounded down below an exclusive maximum", `{"type": "integer", "minimum": 6, "maximum": 7, "exclusiveMaximum": true}`, 6},
+		{"multiple of a minimum", `{"type": "integer", "minimum": 1, "multipleOf": 5}`, 5},
+		{"multiple within bounds", `{"type": "integer", "minimum": 0, "maximum": 10, "multipleOf": 4}`, 8},
+		{"multiple down to stay within bounds", `{"type": "integer", "minimum": 0, "maximum": 7, "multipleOf": 5}`, 5},
+		{"fractional multiple", `{"type": "number", "minimum": 0.1, "multipleOf": 0.25}`, 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenerateExample(schema(t, tt.schema)); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateExample(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   interface{}
+	}{
+		{"documented example", `{"type": "integer", "example": 7, "minimum": 10}`, float64(7)},
+		{"enum", `{"type": "string", "enum": ["b", "a"]}`, "b"},
+		{"format", `{"type": "string", "format": "uuid"}`, "3fa85f64-5717-4562-b3fc-2c963f66afa6"},
+		{"length", `{"type": "string", "minLength": 8, "maxLength": 10}`, "stringxx"},
+		{"array", `{"type": "array", "items": {"type": "boolean"}}`, []interface{}{true}},
+		{
+			"required properties",
+			`{"type": "object", "required": ["id", "name"], "properties": {"id": {"type": "integer", "minimum": 1, "multipleOf": 5}, "name": {"type": "string"}, "note": {"type": "string"}}}`,
+			map[string]interface{}{"id": float64(5), "name": "string"},
+		},
+		{
+			"all of",
+			`{"allOf": [{"type": "object", "properties": {"a": {"type": "integer"}}}, {"type": "object", "properties": {"b": {"type": "boolean"}}}]}`,
+			map[string]interface{}{"a": float64(1), "b": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenerateExample(schema(t, tt.schema)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
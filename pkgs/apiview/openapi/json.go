@@ -0,0 +1,424 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Extensions holds the x- vendor extensions of an object
+type Extensions map[string]interface{}
+
+// ExtensionContainer names a field of an object whose own x- keys should be
+// split off into Target, e.g. the paths object of the document root
+type ExtensionContainer struct {
+	Key    string
+	Target *Extensions
+}
+
+// UnmarshalWithExtensions decodes data into v and collects the x- keys of
+// the object into ext. v is usually an alias of the type being decoded, so
+// its own UnmarshalJSON is not called again.
+func UnmarshalWithExtensions(data []byte, v interface{}, ext *Extensions, containers ...ExtensionContainer) error {
+	if !bytes.Contains(data, []byte(`"x-`)) {
+		return json.Unmarshal(data, v)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		// Not an object, let the typed decode report the error
+		return json.Unmarshal(data, v)
+	}
+
+	var err error
+	if *ext, err = splitExtensions(fields); err != nil {
+		return err
+	}
+
+	changed := false
+	for _, c := range containers {
+		raw, ok := fields[c.Key]
+		if !ok {
+			continue
+		}
+
+		var entries map[string]json.RawMessage
+		if json.Unmarshal(raw, &entries) != nil {
+			continue
+		}
+		if *c.Target, err = splitExtensions(entries); err != nil {
+			return err
+		}
+		if len(*c.Target) == 0 {
+			continue
+		}
+
+		if fields[c.Key], err = json.Marshal(entries); err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	if changed {
+		if data, err = json.Marshal(fields); err != nil {
+			return err
+		}
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// MarshalWithExtensions encodes v, which has to encode to an object or
+// null, and appends the extensions to it
+func MarshalWithExtensions(v interface{}, ext Extensions) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(ext) == 0 {
+		return data, err
+	}
+
+	extData, err := json.Marshal(map[string]interface{}(ext))
+	if err != nil {
+		return nil, err
+	}
+
+	if string(data) == "null" || string(data) == "{}" {
+		return extData, nil
+	}
+
+	out := make([]byte, 0, len(data)+len(extData))
+	out = append(out, data[:len(data)-1]...)
+	out = append(out, ',')
+	out = append(out, extData[1:]...)
+	return out, nil
+}
+
+// splitExtensions removes the x- keys from fields and returns them decoded
+func splitExtensions(fields map[string]json.RawMessage) (Extensions, error) {
+	var ext Extensions
+	for key, raw := range fields {
+		if !strings.HasPrefix(key, "x-") {
+			continue
+		}
+
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		if ext == nil {
+			ext = Extensions{}
+		}
+		ext[key] = v
+		delete(fields, key)
+	}
+	return ext, nil
+}
+
+// DecodeValue decodes a raw value like an example or default, both a missing
+// and a null value are nil
+func DecodeValue(raw json.RawMessage) interface{} {
+	var v interface{}
+	if len(raw) == 0 || json.Unmarshal(raw, &v) != nil {
+		return nil
+	}
+	return v
+}
+
+// optionalList keeps an empty but non-nil list when encoding, omitempty
+// would drop it
+func optionalList[T any](list []T) *[]T {
+	if list == nil {
+		return nil
+	}
+	return &list
+}
+
+func (o *OpenAPI) UnmarshalJSON(data []byte) error {
+	type alias OpenAPI
+	return UnmarshalWithExtensions(data, (*alias)(o), &o.Extensions,
+		ExtensionContainer{Key: "paths", Target: &o.PathsExtensions})
+}
+
+func (o OpenAPI) MarshalJSON() ([]byte, error) {
+	type alias OpenAPI
+
+	var paths json.RawMessage
+	if o.Paths != nil || len(o.PathsExtensions) > 0 {
+		var err error
+		if paths, err = MarshalWithExtensions(o.Paths, o.PathsExtensions); err != nil {
+			return nil, err
+		}
+	}
+
+	return MarshalWithExtensions(struct {
+		alias
+		Paths    json.RawMessage        `json:"paths,omitempty"`
+		Security *[]map[string][]string `json:"security,omitempty"`
+	}{alias(o), paths, optionalList(o.Security)}, o.Extensions)
+}
+
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	type alias Operation
+	return UnmarshalWithExtensions(data, (*alias)(o), &o.Extensions,
+		ExtensionContainer{Key: "responses", Target: &o.ResponsesExtensions})
+}
+
+func (o Operation) MarshalJSON() ([]byte, error) {
+	type alias Operation
+
+	var responses json.RawMessage
+	if o.Responses != nil || len(o.ResponsesExtensions) > 0 {
+		var err error
+		if responses, err = MarshalWithExtensions(o.Responses, o.ResponsesExtensions); err != nil {
+			return nil, err
+		}
+	}
+
+	return MarshalWithExtensions(struct {
+		alias
+		Responses json.RawMessage        `json:"responses,omitempty"`
+		Security  *[]map[string][]string `json:"security,omitempty"`
+	}{alias(o), responses, optionalList(o.Security)}, o.Extensions)
+}
+
+func (c *Callback) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	if raw, ok := fields["$ref"]; ok {
+		if err := json.Unmarshal(raw, &c.Ref); err != nil {
+			return err
+		}
+		delete(fields, "$ref")
+	}
+
+	var err error
+	if c.Extensions, err = splitExtensions(fields); err != nil {
+		return err
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	c.PathItems = make(map[string]PathItem, len(fields))
+	for expr, raw := range fields {
+		var item PathItem
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return err
+		}
+		c.PathItems[expr] = item
+	}
+	return nil
+}
+
+func (c Callback) MarshalJSON() ([]byte, error) {
+	out := make(map[string]interface{}, len(c.PathItems)+1)
+	for expr, item := range c.PathItems {
+		out[expr] = item
+	}
+	if c.Ref != "" {
+		out["$ref"] = c.Ref
+	}
+	return MarshalWithExtensions(out, c.Extensions)
+}
+
+func (i *Info) UnmarshalJSON(data []byte) error {
+	type alias Info
+	return UnmarshalWithExtensions(data, (*alias)(i), &i.Extensions)
+}
+
+func (i Info) MarshalJSON() ([]byte, error) {
+	type alias Info
+	return MarshalWithExtensions(alias(i), i.Extensions)
+}
+
+func (c *Contact) UnmarshalJSON(data []byte) error {
+	type alias Contact
+	return UnmarshalWithExtensions(data, (*alias)(c), &c.Extensions)
+}
+
+func (c Contact) MarshalJSON() ([]byte, error) {
+	type alias Contact
+	return MarshalWithExtensions(alias(c), c.Extensions)
+}
+
+func (l *License) UnmarshalJSON(data []byte) error {
+	type alias License
+	return UnmarshalWithExtensions(data, (*alias)(l), &l.Extensions)
+}
+
+func (l License) MarshalJSON() ([]byte, error) {
+	type alias License
+	return MarshalWithExtensions(alias(l), l.Extensions)
+}
+
+func (p *PathItem) UnmarshalJSON(data []byte) error {
+	type alias PathItem
+	return UnmarshalWithExtensions(data, (*alias)(p), &p.Extensions)
+}
+
+func (p PathItem) MarshalJSON() ([]byte, error) {
+	type alias PathItem
+	return MarshalWithExtensions(alias(p), p.Extensions)
+}
+
+func (p *Parameter) UnmarshalJSON(data []byte) error {
+	type alias Parameter
+	return UnmarshalWithExtensions(data, (*alias)(p), &p.Extensions)
+}
+
+func (p Parameter) MarshalJSON() ([]byte, error) {
+	type alias Parameter
+	return MarshalWithExtensions(alias(p), p.Extensions)
+}
+
+func (r *RequestBody) UnmarshalJSON(data []byte) error {
+	type alias RequestBody
+	return UnmarshalWithExtensions(data, (*alias)(r), &r.Extensions)
+}
+
+func (r RequestBody) MarshalJSON() ([]byte, error) {
+	type alias RequestBody
+	return MarshalWithExtensions(alias(r), r.Extensions)
+}
+
+func (r *Response) UnmarshalJSON(data []byte) error {
+	type alias Response
+	return UnmarshalWithExtensions(data, (*alias)(r), &r.Extensions)
+}
+
+func (r Response) MarshalJSON() ([]byte, error) {
+	type alias Response
+	return MarshalWithExtensions(alias(r), r.Extensions)
+}
+
+func (m *MediaType) UnmarshalJSON(data []byte) error {
+	type alias MediaType
+	return UnmarshalWithExtensions(data, (*alias)(m), &m.Extensions)
+}
+
+func (m MediaType) MarshalJSON() ([]byte, error) {
+	type alias MediaType
+	return MarshalWithExtensions(alias(m), m.Extensions)
+}
+
+func (e *Encoding) UnmarshalJSON(data []byte) error {
+	type alias Encoding
+	return UnmarshalWithExtensions(data, (*alias)(e), &e.Extensions)
+}
+
+func (e Encoding) MarshalJSON() ([]byte, error) {
+	type alias Encoding
+	return MarshalWithExtensions(alias(e), e.Extensions)
+}
+
+func (h *Header) UnmarshalJSON(data []byte) error {
+	type alias Header
+	return UnmarshalWithExtensions(data, (*alias)(h), &h.Extensions)
+}
+
+func (h Header) MarshalJSON() ([]byte, error) {
+	type alias Header
+	return MarshalWithExtensions(alias(h), h.Extensions)
+}
+
+func (l *Link) UnmarshalJSON(data []byte) error {
+	type alias Link
+	return UnmarshalWithExtensions(data, (*alias)(l), &l.Extensions)
+}
+
+func (l Link) MarshalJSON() ([]byte, error) {
+	type alias Link
+	return MarshalWithExtensions(alias(l), l.Extensions)
+}
+
+func (c *Components) UnmarshalJSON(data []byte) error {
+	type alias Components
+	return UnmarshalWithExtensions(data, (*alias)(c), &c.Extensions)
+}
+
+func (c Components) MarshalJSON() ([]byte, error) {
+	type alias Components
+	return MarshalWithExtensions(alias(c), c.Extensions)
+}
+
+func (s *SecurityScheme) UnmarshalJSON(data []byte) error {
+	type alias SecurityScheme
+	return UnmarshalWithExtensions(data, (*alias)(s), &s.Extensions)
+}
+
+func (s SecurityScheme) MarshalJSON() ([]byte, error) {
+	type alias SecurityScheme
+	return MarshalWithExtensions(alias(s), s.Extensions)
+}
+
+func (o *OAuthFlows) UnmarshalJSON(data []byte) error {
+	type alias OAuthFlows
+	return UnmarshalWithExtensions(data, (*alias)(o), &o.Extensions)
+}
+
+func (o OAuthFlows) MarshalJSON() ([]byte, error) {
+	type alias OAuthFlows
+	return MarshalWithExtensions(alias(o), o.Extensions)
+}
+
+func (o *OAuthFlow) UnmarshalJSON(data []byte) error {
+	type alias OAuthFlow
+	return UnmarshalWithExtensions(data, (*alias)(o), &o.Extensions)
+}
+
+func (o OAuthFlow) MarshalJSON() ([]byte, error) {
+	type alias OAuthFlow
+	return MarshalWithExtensions(alias(o), o.Extensions)
+}
+
+func (s *Server) UnmarshalJSON(data []byte) error {
+	type alias Server
+	return UnmarshalWithExtensions(data, (*alias)(s), &s.Extensions)
+}
+
+func (s Server) MarshalJSON() ([]byte, error) {
+	type alias Server
+	return MarshalWithExtensions(alias(s), s.Extensions)
+}
+
+func (s *ServerVariable) UnmarshalJSON(data []byte) error {
+	type alias ServerVariable
+	return UnmarshalWithExtensions(data, (*alias)(s), &s.Extensions)
+}
+
+func (s ServerVariable) MarshalJSON() ([]byte, error) {
+	type alias ServerVariable
+	return MarshalWithExtensions(alias(s), s.Extensions)
+}
+
+func (t *Tag) UnmarshalJSON(data []byte) error {
+	type alias Tag
+	return UnmarshalWithExtensions(data, (*alias)(t), &t.Extensions)
+}
+
+func (t Tag) MarshalJSON() ([]byte, error) {
+	type alias Tag
+	return MarshalWithExtensions(alias(t), t.Extensions)
+}
+
+func (e *ExternalDocumentation) UnmarshalJSON(data []byte) error {
+	type alias ExternalDocumentation
+	return UnmarshalWithExtensions(data, (*alias)(e), &e.Extensions)
+}
+
+func (e ExternalDocumentation) MarshalJSON() ([]byte, error) {
+	type alias ExternalDocumentation
+	return MarshalWithExtensions(alias(e), e.Extensions)
+}
+
+func (e *Example) UnmarshalJSON(data []byte) error {
+	type alias Example
+	return UnmarshalWithExtensions(data, (*alias)(e), &e.Extensions)
+}
+
+func (e Example) MarshalJSON() ([]byte, error) {
+	type alias Example
+	return MarshalWithExtensions(alias(e), e.Extensions)
+}
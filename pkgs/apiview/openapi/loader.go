@@ -0,0 +1,604 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the serialization a spec document is written in
+type Format int
+
+const (
+	FormatJSON Format = iota
+	FormatYAML
+)
+
+func (f Format) String() string {
+	if f == FormatYAML {
+		return "yaml"
+	}
+	return "json"
+}
+
+// StdinPath is the path that makes Load read the spec from stdin
+const StdinPath = "-"
+
+// DecodeError describes where in a spec document decoding failed
+type DecodeError struct {
+	Source  string // File the document was read from, empty if unknown
+	Line    int    // 1-based line, 0 if unknown
+	Column  int    // 1-based column, 0 if unknown
+	Pointer string // JSON pointer to the offending value, empty if unknown
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	var b strings.Builder
+	if e.Source != "" {
+		b.WriteString(e.Source)
+		b.WriteString(":")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "%d:", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, "%d:", e.Column)
+		}
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	b.WriteString(e.Err.Error())
+	if e.Pointer != "" {
+		fmt.Fprintf(&b, " (at #%s)", e.Pointer)
+	}
+	return b.String()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Load reads and decodes the OpenAPI document at path, "-" reads from stdin
+func Load(path string) (*OpenAPI, error) {
+	if path == StdinPath {
+		return LoadReader(os.Stdin, "<stdin>")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	doc, err := LoadReader(f, path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.location, err = filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// LoadReader decodes an OpenAPI document from r, source is only used for error messages
+func LoadReader(r io.Reader, source string) (*OpenAPI, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &OpenAPI{}
+	if err := Unmarshal(data, doc); err != nil {
+		var decErr *DecodeError
+		if errors.As(err, &decErr) {
+			decErr.Source = source
+		}
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Parse decodes an OpenAPI document from JSON or YAML data
+func Parse(data []byte) (*OpenAPI, error) {
+	doc := &OpenAPI{}
+	if err := Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// DetectFormat guesses whether data is JSON or YAML. As JSON is valid YAML
+// anything not starting like a JSON object or array is treated as YAML.
+func DetectFormat(data []byte) Format {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// Unmarshal decodes JSON or YAML data into v using v's json tags. Failures
+// are returned as *DecodeError.
+func Unmarshal(data []byte, v interface{}) error {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if DetectFormat(data) == FormatJSON {
+		return unmarshalJSON(data, v)
+	}
+	return unmarshalYAML(data, v)
+}
+
+func unmarshalJSON(data []byte, v interface{}) error {
+	err := json.Unmarshal(data, v)
+	if err == nil {
+		return nil
+	}
+
+	decErr := &DecodeError{Err: err}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		decErr.Line, decErr.Column = lineColumn(data, syntaxErr.Offset)
+		return decErr
+	}
+
+	// JSON is valid YAML, the node tree gives us positions for the checks
+	// in locate
+	var root yaml.Node
+	if yaml.Unmarshal(data, &root) == nil && decErr.locate(&root, v) {
+		return decErr
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		decErr.Line, decErr.Column = lineColumn(data, typeErr.Offset)
+		decErr.Pointer = pointerAtOffset(data, typeErr.Offset)
+	}
+
+	return decErr
+}
+
+var yamlLineRe = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+func unmarshalYAML(data []byte, v interface{}) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		decErr := &DecodeError{Err: err}
+		if m := yamlLineRe.FindStringSubmatch(err.Error()); m != nil {
+			decErr.Line, _ = strconv.Atoi(m[1])
+			decErr.Err = errors.New(m[2])
+		}
+		return decErr
+	}
+
+	generic, err := yamlNodeToValue(&root)
+	if err != nil {
+		return &DecodeError{Err: err}
+	}
+
+	jsonData, err := json.Marshal(generic)
+	if err != nil {
+		return &DecodeError{Err: err}
+	}
+
+	err = json.Unmarshal(jsonData, v)
+	if err == nil {
+		return nil
+	}
+
+	decErr := &DecodeError{Err: err}
+	if decErr.locate(&root, v) {
+		return decErr
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		decErr.Pointer = pointerAtOffset(jsonData, typeErr.Offset)
+		if node := yamlNodeAt(&root, decErr.Pointer); node != nil {
+			decErr.Line, decErr.Column = node.Line, node.Column
+		}
+	}
+
+	return decErr
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// locate finds the first value in the tree that cannot be decoded into
+// v's type. The decoder reports offsets relative to the innermost custom
+// UnmarshalJSON, which is useless for pointing at the spot in the file.
+func (e *DecodeError) locate(root *yaml.Node, v interface{}) bool {
+	node, pointer := findMismatch(root, reflect.TypeOf(v), "")
+	if node == nil {
+		return false
+	}
+
+	e.Pointer = pointer
+	e.Line, e.Column = node.Line, node.Column
+	return true
+}
+
+func findMismatch(node *yaml.Node, t reflect.Type, pointer string) (*yaml.Node, string) {
+	for node.Kind == yaml.DocumentNode || node.Kind == yaml.AliasNode {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		} else if len(node.Content) > 0 {
+			node = node.Content[0]
+		} else {
+			return nil, ""
+		}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null" {
+		return nil, ""
+	}
+
+	// Types with their own UnmarshalJSON may accept other shapes, only
+	// look into them where they are plain objects
+	custom := reflect.PointerTo(t).Implements(jsonUnmarshalerType)
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return nil, ""
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			if custom {
+				return nil, ""
+			}
+			return node, pointer
+		}
+		fields := jsonFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			field, ok := fields[key]
+			if !ok {
+				for name, f := range fields {
+					if strings.EqualFold(name, key) {
+						field, ok = f, true
+						break
+					}
+				}
+			}
+			if !ok {
+				continue
+			}
+			if n, p := findMismatch(node.Content[i+1], field.Type, pointer+"/"+escapePointerToken(key)); n != nil {
+				return n, p
+			}
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			if custom {
+				return nil, ""
+			}
+			return node, pointer
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if strings.HasPrefix(key, "x-") {
+				continue
+			}
+			if n, p := findMismatch(node.Content[i+1], t.Elem(), pointer+"/"+escapePointerToken(key)); n != nil {
+				return n, p
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			if custom {
+				return nil, ""
+			}
+			return node, pointer
+		}
+		for i, item := range node.Content {
+			if n, p := findMismatch(item, t.Elem(), pointer+"/"+strconv.Itoa(i)); n != nil {
+				return n, p
+			}
+		}
+	default:
+		if custom {
+			return nil, ""
+		}
+		if node.Kind != yaml.ScalarNode || !scalarFits(node.ShortTag(), t.Kind()) {
+			return node, pointer
+		}
+	}
+
+	return nil, ""
+}
+
+func scalarFits(tag string, kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String:
+		return tag == "!!str" || tag == "!!timestamp" || tag == "!!binary"
+	case reflect.Bool:
+		return tag == "!!bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return tag == "!!int"
+	case reflect.Float32, reflect.Float64:
+		return tag == "!!int" || tag == "!!float"
+	}
+	return true
+}
+
+// jsonFields returns the fields of a struct by their JSON name, including
+// the promoted fields of embedded structs
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for n, ef := range jsonFields(embedded) {
+					if _, exists := fields[n]; !exists {
+						fields[n] = ef
+					}
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// yamlNodeToValue converts a YAML node into the values encoding/json would
+// produce. Timestamps stay strings and mapping keys are stringified, so the
+// result always survives a trip through json.Marshal.
+func yamlNodeToValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlNodeToValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlNodeToValue(node.Alias)
+	case yaml.SequenceNode:
+		list := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			v, err := yamlNodeToValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case yaml.MappingNode:
+		obj := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valNode := node.Content[i], node.Content[i+1]
+
+			if keyNode.ShortTag() == "!!merge" {
+				if err := mergeYAMLValue(obj, valNode); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			v, err := yamlNodeToValue(valNode)
+			if err != nil {
+				return nil, err
+			}
+			obj[keyNode.Value] = v
+		}
+		return obj, nil
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null":
+			return nil, nil
+		case "!!bool", "!!int", "!!float":
+			var v interface{}
+			if err := node.Decode(&v); err != nil {
+				return nil, fmt.Errorf("line %d: %w", node.Line, err)
+			}
+			return v, nil
+		default:
+			return node.Value, nil
+		}
+	}
+
+	return nil, fmt.Errorf("line %d: unsupported YAML node", node.Line)
+}
+
+// mergeYAMLValue applies a "<<" merge key, keys already present win
+func mergeYAMLValue(obj map[string]interface{}, node *yaml.Node) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	sources := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		sources = node.Content
+	}
+
+	for _, src := range sources {
+		v, err := yamlNodeToValue(src)
+		if err != nil {
+			return err
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("line %d: merge value is not a mapping", src.Line)
+		}
+		for k, val := range m {
+			if _, exists := obj[k]; !exists {
+				obj[k] = val
+			}
+		}
+	}
+
+	return nil
+}
+
+// yamlNodeAt walks the YAML tree along a JSON pointer
+func yamlNodeAt(root *yaml.Node, pointer string) *yaml.Node {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	for _, token := range splitPointer(pointer) {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == token {
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			idx, err := strconv.Atoi(token)
+			if err == nil && idx >= 0 && idx < len(node.Content) {
+				next = node.Content[idx]
+			}
+		}
+
+		if next == nil {
+			return node
+		}
+		node = next
+	}
+
+	return node
+}
+
+// pointerAtOffset returns the JSON pointer of the value the decoder was
+// reading when it stopped at offset
+func pointerAtOffset(data []byte, offset int64) string {
+	type frame struct {
+		object  bool
+		key     string
+		haveKey bool
+		index   int
+	}
+
+	var stack []*frame
+	pointer := func() string {
+		var b strings.Builder
+		for _, f := range stack {
+			b.WriteString("/")
+			if f.object {
+				b.WriteString(escapePointerToken(f.key))
+			} else {
+				b.WriteString(strconv.Itoa(f.index))
+			}
+		}
+		return b.String()
+	}
+	valueDone := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.object {
+			top.haveKey = false
+		} else {
+			top.index++
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return pointer()
+		}
+
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			valueDone()
+			continue
+		}
+
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if top.object && !top.haveKey {
+				top.key, _ = tok.(string)
+				top.haveKey = true
+				continue
+			}
+		}
+
+		if dec.InputOffset() >= offset {
+			return pointer()
+		}
+
+		if delim, ok := tok.(json.Delim); ok {
+			stack = append(stack, &frame{object: delim == '{'})
+			continue
+		}
+		valueDone()
+	}
+}
+
+// lineColumn converts a byte offset into a 1-based line and column
+func lineColumn(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+func escapePointerToken(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func unescapePointerToken(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
+
+func splitPointer(pointer string) []string {
+	pointer = strings.TrimPrefix(pointer, "#")
+	if pointer == "" {
+		return nil
+	}
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, t := range tokens {
+		tokens[i] = unescapePointerToken(t)
+	}
+	return tokens
+}
@@ -0,0 +1,137 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestRoundTrip loads every spec in testdata and checks that marshaling it
+// again gives the same document
+func TestRoundTrip(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no specs in testdata")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			doc, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := readGeneric(t, path)
+			var got interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range diffValues("", want, got) {
+				t.Error(d)
+			}
+		})
+	}
+}
+
+// readGeneric decodes the spec at path into plain JSON values
+func readGeneric(t *testing.T, path string) interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if DetectFormat(data) == FormatYAML {
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			t.Fatal(err)
+		}
+		v, err := yamlNodeToValue(&node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, err = json.Marshal(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// diffValues lists where got differs from want, by dotted path
+func diffValues(path string, want, got interface{}) []string {
+	wantObj, ok1 := want.(map[string]interface{})
+	gotObj, ok2 := got.(map[string]interface{})
+	if ok1 && ok2 {
+		keys := map[string]bool{}
+		for k := range wantObj {
+			keys[k] = true
+		}
+		for k := range gotObj {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		var diffs []string
+		for _, k := range sorted {
+			sub := k
+			if path != "" {
+				sub = path + "." + k
+			}
+			w, inWant := wantObj[k]
+			g, inGot := gotObj[k]
+			switch {
+			case !inGot && w == false:
+				// false is the default of every boolean field, it's omitted
+			case !inGot:
+				diffs = append(diffs, fmt.Sprintf("%s: %s disappeared", sub, jsonString(w)))
+			case !inWant:
+				diffs = append(diffs, fmt.Sprintf("%s: %s was added", sub, jsonString(g)))
+			default:
+				diffs = append(diffs, diffValues(sub, w, g)...)
+			}
+		}
+		return diffs
+	}
+
+	wantList, ok1 := want.([]interface{})
+	gotList, ok2 := got.([]interface{})
+	if ok1 && ok2 && len(wantList) == len(gotList) {
+		var diffs []string
+		for i := range wantList {
+			diffs = append(diffs, diffValues(fmt.Sprintf("%s[%d]", path, i), wantList[i], gotList[i])...)
+		}
+		return diffs
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		return []string{fmt.Sprintf("%s: %s came back as %s", path, jsonString(want), jsonString(got))}
+	}
+	return nil
+}
+
+func jsonString(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,292 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrCircularRef is returned when a $ref chain points back to itself without
+// ever reaching a value
+var ErrCircularRef = errors.New("circular $ref")
+
+// Resolver follows $ref pointers inside a document and into files relative
+// to it. Recursive structures (e.g. a tree schema) are expanded once, the
+// recurring reference is left as a plain $ref.
+type Resolver struct {
+	location string
+	root     interface{}
+	docs     map[string]interface{}
+	expanded map[string]interface{}
+}
+
+// NewResolver prepares a resolver for doc. Relative file refs are looked up
+// next to the file doc was loaded from, or the working directory.
+func NewResolver(doc *OpenAPI) (*Resolver, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	return &Resolver{
+		location: doc.location,
+		root:     root,
+		docs:     map[string]interface{}{},
+		expanded: map[string]interface{}{},
+	}, nil
+}
+
+// Resolve returns a copy of doc with every $ref replaced by what it points
+// at, see Resolver.Resolve
+func Resolve(doc *OpenAPI) (*OpenAPI, error) {
+	r, err := NewResolver(doc)
+	if err != nil {
+		return nil, err
+	}
+	return r.Resolve()
+}
+
+// Resolve returns a resolved copy of the document. Inlined objects keep
+// their $ref, so the Ref fields still name where a value came from.
+func (r *Resolver) Resolve() (*OpenAPI, error) {
+	resolved, _, err := r.resolveValue(r.root, r.location, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &OpenAPI{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	doc.location = r.location
+
+	return doc, nil
+}
+
+// ResolveRef decodes the fully resolved target of ref into v. Relative refs
+// are taken relative to the root document.
+func (r *Resolver) ResolveRef(ref string, v interface{}) error {
+	resolved, _, err := r.resolveValue(map[string]interface{}{"$ref": ref}, r.location, nil)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(resolved)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// RefName returns the short name of a ref, e.g. "User" for
+// "#/components/schemas/User" or "./schemas/user.yaml#/User"
+func RefName(ref string) string {
+	file, fragment, _ := strings.Cut(ref, "#")
+	if tokens := splitPointer(fragment); len(tokens) > 0 {
+		return tokens[len(tokens)-1]
+	}
+	return strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+}
+
+// resolveValue returns a copy of v with refs expanded. The bool reports
+// whether a recursive ref had to be left unexpanded somewhere below v.
+func (r *Resolver) resolveValue(v interface{}, location string, stack []string) (interface{}, bool, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := val["$ref"].(string); ok {
+			return r.resolveRef(val, ref, location, stack)
+		}
+
+		truncated := false
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			resolved, t, err := r.resolveValue(item, location, stack)
+			if err != nil {
+				return nil, false, err
+			}
+			truncated = truncated || t
+			out[k] = resolved
+		}
+		return out, truncated, nil
+	case []interface{}:
+		truncated := false
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			resolved, t, err := r.resolveValue(item, location, stack)
+			if err != nil {
+				return nil, false, err
+			}
+			truncated = truncated || t
+			out[i] = resolved
+		}
+		return out, truncated, nil
+	default:
+		return v, false, nil
+	}
+}
+
+func (r *Resolver) resolveRef(refObj map[string]interface{}, ref string, location string, stack []string) (interface{}, bool, error) {
+	target, targetLocation, key, err := r.follow(ref, location)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, seen := range stack {
+		if seen == key {
+			return refObj, true, nil
+		}
+	}
+
+	resolved, ok := r.expanded[key]
+	truncated := false
+	if !ok {
+		resolved, truncated, err = r.resolveValue(target, targetLocation, append(stack, key))
+		if err != nil {
+			return nil, false, err
+		}
+		if !truncated {
+			r.expanded[key] = resolved
+		}
+	}
+
+	obj, isObj := resolved.(map[string]interface{})
+	if !isObj {
+		return resolved, truncated, nil
+	}
+
+	// Keep the ref and any siblings next to it, OpenAPI 3.1 allows
+	// overriding summary and description this way
+	out := make(map[string]interface{}, len(obj)+len(refObj))
+	for k, item := range obj {
+		out[k] = item
+	}
+	for k, item := range refObj {
+		out[k] = item
+	}
+
+	return out, truncated, nil
+}
+
+// follow walks a chain of refs until it reaches something that is not a
+// ref, returning that value, the location of its document and its
+// canonical key
+func (r *Resolver) follow(ref string, location string) (interface{}, string, string, error) {
+	seen := map[string]bool{}
+	for {
+		targetLocation, fragment, err := r.splitRef(ref, location)
+		if err != nil {
+			return nil, "", "", err
+		}
+
+		key := targetLocation + "#" + fragment
+		if seen[key] {
+			return nil, "", "", fmt.Errorf("%w: %s", ErrCircularRef, ref)
+		}
+		seen[key] = true
+
+		doc, err := r.document(targetLocation)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("resolving %s: %w", ref, err)
+		}
+
+		target, err := lookupPointer(doc, fragment)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("resolving %s: %w", ref, err)
+		}
+
+		if next, ok := target.(map[string]interface{}); ok {
+			if nextRef, ok := next["$ref"].(string); ok && len(next) == 1 {
+				ref, location = nextRef, targetLocation
+				continue
+			}
+		}
+
+		return target, targetLocation, key, nil
+	}
+}
+
+// splitRef turns ref into the absolute location of its document and the
+// JSON pointer inside of it
+func (r *Resolver) splitRef(ref string, location string) (string, string, error) {
+	file, fragment, _ := strings.Cut(ref, "#")
+	if file == "" {
+		return location, fragment, nil
+	}
+
+	if strings.Contains(file, "://") {
+		return "", "", fmt.Errorf("resolving %s: remote refs are not supported", ref)
+	}
+
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(filepath.Dir(location), file)
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", "", err
+	}
+
+	return abs, fragment, nil
+}
+
+func (r *Resolver) document(location string) (interface{}, error) {
+	if location == r.location {
+		return r.root, nil
+	}
+	if doc, ok := r.docs[location]; ok {
+		return doc, nil
+	}
+
+	data, err := os.ReadFile(location)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := Unmarshal(data, &doc); err != nil {
+		var decErr *DecodeError
+		if errors.As(err, &decErr) {
+			decErr.Source = location
+		}
+		return nil, err
+	}
+
+	r.docs[location] = doc
+	return doc, nil
+}
+
+func lookupPointer(doc interface{}, pointer string) (interface{}, error) {
+	current := doc
+	for _, token := range splitPointer(pointer) {
+		switch val := current.(type) {
+		case map[string]interface{}:
+			next, ok := val[token]
+			if !ok {
+				return nil, fmt.Errorf("no %q in #%s", token, pointer)
+			}
+			current = next
+		case []interface{}:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(val) {
+				return nil, fmt.Errorf("invalid index %q in #%s", token, pointer)
+			}
+			current = val[idx]
+		default:
+			return nil, fmt.Errorf("cannot descend into %q in #%s", token, pointer)
+		}
+	}
+	return current, nil
+}
@@ -0,0 +1,241 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Schema is a JSON Schema object. It covers both the OpenAPI 3.0 dialect
+// (nullable, boolean exclusive bounds, single example) and JSON Schema
+// 2020-12 used by OpenAPI 3.1 (type arrays, const, prefixItems, $defs).
+type Schema struct {
+	Ref           string             `json:"$ref,omitempty"`
+	Schema        string             `json:"$schema,omitempty"`
+	ID            string             `json:"$id,omitempty"`
+	Anchor        string             `json:"$anchor,omitempty"`
+	DynamicRef    string             `json:"$dynamicRef,omitempty"`
+	DynamicAnchor string             `json:"$dynamicAnchor,omitempty"`
+	Comment       string             `json:"$comment,omitempty"`
+	Defs          map[string]*Schema `json:"$defs,omitempty"`
+
+	Type        SchemaType      `json:"type,omitempty"`
+	Format      string          `json:"format,omitempty"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Default     json.RawMessage `json:"default,omitempty"`
+	Const       json.RawMessage `json:"const,omitempty"`
+	Enum        []interface{}   `json:"enum,omitempty"`
+	Example     json.RawMessage `json:"example,omitempty"`  // OpenAPI 3.0
+	Examples    []interface{}   `json:"examples,omitempty"` // OpenAPI 3.1
+
+	MultipleOf       *float64    `json:"multipleOf,omitempty"`
+	Maximum          *float64    `json:"maximum,omitempty"`
+	ExclusiveMaximum interface{} `json:"exclusiveMaximum,omitempty"` // bool in 3.0, number in 3.1
+	Minimum          *float64    `json:"minimum,omitempty"`
+	ExclusiveMinimum interface{} `json:"exclusiveMinimum,omitempty"` // bool in 3.0, number in 3.1
+
+	MaxLength        *int    `json:"maxLength,omitempty"`
+	MinLength        *int    `json:"minLength,omitempty"`
+	Pattern          string  `json:"pattern,omitempty"`
+	ContentEncoding  string  `json:"contentEncoding,omitempty"`
+	ContentMediaType string  `json:"contentMediaType,omitempty"`
+	ContentSchema    *Schema `json:"contentSchema,omitempty"`
+
+	Items            *Schema   `json:"items,omitempty"`
+	PrefixItems      []*Schema `json:"prefixItems,omitempty"`
+	Contains         *Schema   `json:"contains,omitempty"`
+	MaxContains      *int      `json:"maxContains,omitempty"`
+	MinContains      *int      `json:"minContains,omitempty"`
+	MaxItems         *int      `json:"maxItems,omitempty"`
+	MinItems         *int      `json:"minItems,omitempty"`
+	UniqueItems      bool      `json:"uniqueItems,omitempty"`
+	UnevaluatedItems *Schema   `json:"unevaluatedItems,omitempty"`
+
+	Properties            map[string]*Schema  `json:"properties,omitempty"`
+	PatternProperties     map[string]*Schema  `json:"patternProperties,omitempty"`
+	AdditionalProperties  *Schema             `json:"additionalProperties,omitempty"`
+	UnevaluatedProperties *Schema             `json:"unevaluatedProperties,omitempty"`
+	PropertyNames         *Schema             `json:"propertyNames,omitempty"`
+	Required              []string            `json:"required,omitempty"`
+	MaxProperties         *int                `json:"maxProperties,omitempty"`
+	MinProperties         *int                `json:"minProperties,omitempty"`
+	DependentRequired     map[string][]string `json:"dependentRequired,omitempty"`
+	DependentSchemas      map[string]*Schema  `json:"dependentSchemas,omitempty"`
+
+	AllOf []*Schema `json:"allOf,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	Not   *Schema   `json:"not,omitempty"`
+	If    *Schema   `json:"if,omitempty"`
+	Then  *Schema   `json:"then,omitempty"`
+	Else  *Schema   `json:"else,omitempty"`
+
+	Discriminator *Discriminator         `json:"discriminator,omitempty"`
+	Nullable      bool                   `json:"nullable,omitempty"` // OpenAPI 3.0
+	ReadOnly      bool                   `json:"readOnly,omitempty"`
+	WriteOnly     bool                   `json:"writeOnly,omitempty"`
+	Deprecated    bool                   `json:"deprecated,omitempty"`
+	XML           *XML                   `json:"xml,omitempty"`
+	ExternalDocs  *ExternalDocumentation `json:"externalDocs,omitempty"`
+	Extensions    Extensions             `json:"-"`
+
+	// Bool is set for the boolean schemas true and false, in which case all
+	// other fields are empty
+	Bool *bool `json:"-"`
+}
+
+// SchemaType holds the allowed types of a schema. OpenAPI 3.0 only allows
+// a single type, 3.1 also accepts a list.
+type SchemaType []string
+
+// Is reports whether t is one of the allowed types
+func (st SchemaType) Is(t string) bool {
+	for _, s := range st {
+		if s == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (st *SchemaType) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return json.Unmarshal(data, (*[]string)(st))
+	}
+
+	var single string
+	if err := json.Unmarshal(data, &single); err != nil {
+		return err
+	}
+	*st = SchemaType{single}
+	return nil
+}
+
+func (st SchemaType) MarshalJSON() ([]byte, error) {
+	if len(st) == 1 {
+		return json.Marshal(st[0])
+	}
+	return json.Marshal([]string(st))
+}
+
+// Discriminator helps to tell apart the schemas of a oneOf or anyOf
+type Discriminator struct {
+	PropertyName string            `json:"propertyName"`
+	Mapping      map[string]string `json:"mapping,omitempty"`
+	Extensions   Extensions        `json:"-"`
+}
+
+// XML describes how a schema is represented as XML
+type XML struct {
+	Name       string     `json:"name,omitempty"`
+	Namespace  string     `json:"namespace,omitempty"`
+	Prefix     string     `json:"prefix,omitempty"`
+	Attribute  bool       `json:"attribute,omitempty"`
+	Wrapped    bool       `json:"wrapped,omitempty"`
+	Extensions Extensions `json:"-"`
+}
+
+// IsNullable reports whether null is a valid value, in either dialect
+func (s *Schema) IsNullable() bool {
+	return s.Nullable || s.Type.Is("null")
+}
+
+// ExclusiveMaximumValue returns the exclusive upper bound, for 3.0 schemas
+// this is Maximum if ExclusiveMaximum is true
+func (s *Schema) ExclusiveMaximumValue() (float64, bool) {
+	return exclusiveBound(s.ExclusiveMaximum, s.Maximum)
+}
+
+// ExclusiveMinimumValue returns the exclusive lower bound, for 3.0 schemas
+// this is Minimum if ExclusiveMinimum is true
+func (s *Schema) ExclusiveMinimumValue() (float64, bool) {
+	return exclusiveBound(s.ExclusiveMinimum, s.Minimum)
+}
+
+func exclusiveBound(exclusive interface{}, inclusive *float64) (float64, bool) {
+	switch v := exclusive.(type) {
+	case float64:
+		return v, true
+	case bool:
+		if v && inclusive != nil {
+			return *inclusive, true
+		}
+	}
+	return 0, false
+}
+
+// WalkSchema calls fn for s and every schema nested in it, depth first.
+// Unresolved refs are not followed.
+func WalkSchema(s *Schema, fn func(*Schema)) {
+	if s == nil {
+		return
+	}
+	fn(s)
+
+	for _, sub := range []*Schema{
+		s.ContentSchema, s.Items, s.Contains, s.UnevaluatedItems,
+		s.AdditionalProperties, s.UnevaluatedProperties, s.PropertyNames,
+		s.Not, s.If, s.Then, s.Else,
+	} {
+		WalkSchema(sub, fn)
+	}
+	for _, list := range [][]*Schema{s.PrefixItems, s.AllOf, s.AnyOf, s.OneOf} {
+		for _, sub := range list {
+			WalkSchema(sub, fn)
+		}
+	}
+	for _, m := range []map[string]*Schema{s.Defs, s.Properties, s.PatternProperties, s.DependentSchemas} {
+		for _, sub := range m {
+			WalkSchema(sub, fn)
+		}
+	}
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true", "false":
+		var b bool
+		if err := json.Unmarshal(data, &b); err != nil {
+			return err
+		}
+		*s = Schema{Bool: &b}
+		return nil
+	}
+
+	type alias Schema
+	return UnmarshalWithExtensions(data, (*alias)(s), &s.Extensions)
+}
+
+func (s Schema) MarshalJSON() ([]byte, error) {
+	if s.Bool != nil {
+		return json.Marshal(*s.Bool)
+	}
+
+	type alias Schema
+	return MarshalWithExtensions(alias(s), s.Extensions)
+}
+
+// UnmarshalJSON also accepts the plain property name Swagger 2.0 uses
+func (d *Discriminator) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		return json.Unmarshal(data, &d.PropertyName)
+	}
+
+	type alias Discriminator
+	return UnmarshalWithExtensions(data, (*alias)(d), &d.Extensions)
+}
+
+func (d Discriminator) MarshalJSON() ([]byte, error) {
+	type alias Discriminator
+	return MarshalWithExtensions(alias(d), d.Extensions)
+}
+
+func (x *XML) UnmarshalJSON(data []byte) error {
+	type alias XML
+	return UnmarshalWithExtensions(data, (*alias)(x), &x.Extensions)
+}
+
+func (x XML) MarshalJSON() ([]byte, error) {
+	type alias XML
+	return MarshalWithExtensions(alias(x), x.Extensions)
+}
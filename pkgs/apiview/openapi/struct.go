@@ -1,95 +1,73 @@
-// package apiview
-
-// // OpenApi Structs
-// type OpenApiCollection struct {
-// 	BasePath string                 `json:"basePath"`
-// 	Host     string                 `json:"host"`
-// 	Schemes  []string               `json:"schemes"`
-// 	Protocol string                 `json:"protocol"`
-// 	Info     OpenApiCollectionInfo  `json:"info"`
-// 	Tags     []OpenApiTag           `json:"tags"`
-// 	PathsRaw map[string]OpenApiPath `json:"paths"`
-// 	Paths    []OpenApiPath          `json:"..."`
-// }
-//
-// type OpenApiTag struct {
-// 	Name        string
-// 	Description string
-// }
-//
-// type OpenApiCollectionInfo struct {
-// 	Title       string `json:"title"`
-// 	Version     string `json:"version"`
-// 	Description string `json:"description"`
-// 	Contact     struct {
-// 		Email                   string `json:"email"`
-// 		ResponsibleDeveloper    string `json:"responsibleDeveloper"`
-// 		ResponsibleOrganization string `json:"responsibleOrganization"`
-// 		Url                     string `json:"url"`
-// 	}
-// }
-//
-// type OpenApiPath struct {
-// 	Method map[string]OpenApiEndpoint
-// }
-//
-// type OpenApiEndpoint struct {
-// 	Parameters []struct {
-// 		In   string `json:"in"`
-// 		Name string `json:"name"`
-// 		Type string `json:"type"`
-// 	} `json:"parameters"`
-// 	Produces  []string                   `json:"produces"`
-// 	Responses map[string]OpenApiResponse `json:"responses"`
-// 	Summary   string                     `json:"summary"`
-// 	Tags      []string                   `json:"tags"`
-// }
-//
-// type OpenApiResponse struct {
-// 	Description string `json:"description"`
-// }
-
 package openapi
 
-// TODO: Check if fields are missing and used correct, missing 300 lines while unmarshaling and marshaling the httpbin.org OAS file
+import "encoding/json"
+
+// The structs cover the OpenAPI 3.0 and 3.1 object model. Every object that
+// allows them keeps its x- vendor extensions in Extensions, see json.go for
+// how they are read and written back. Examples, defaults and other values
+// of any type stay raw JSON, so an explicit null isn't lost on the way.
 
 // OpenAPI represents the root of the OpenAPI document
 type OpenAPI struct {
-	OpenAPI      string                 `json:"openapi"`
-	Info         Info                   `json:"info"`
-	Paths        map[string]PathItem    `json:"paths"`
-	Components   *Components            `json:"components,omitempty"`
-	Servers      []Server               `json:"servers,omitempty"`
-	Tags         []Tag                  `json:"tags,omitempty"`
-	Security     []map[string][]string  `json:"security,omitempty"`
-	ExternalDocs *ExternalDocumentation `json:"externalDocs,omitempty"`
+	OpenAPI           string                 `json:"openapi"`
+	Info              Info                   `json:"info"`
+	JSONSchemaDialect string                 `json:"jsonSchemaDialect,omitempty"`
+	Servers           []Server               `json:"servers,omitempty"`
+	Paths             map[string]PathItem    `json:"paths"`
+	PathsExtensions   Extensions             `json:"-"` // x- extensions of the paths object
+	Webhooks          map[string]PathItem    `json:"webhooks,omitempty"`
+	Components        *Components            `json:"components,omitempty"`
+	Security          []map[string][]string  `json:"security,omitempty"`
+	Tags              []Tag                  `json:"tags,omitempty"`
+	ExternalDocs      *ExternalDocumentation `json:"externalDocs,omitempty"`
+	Extensions        Extensions             `json:"-"`
+
+	location string // Absolute path of the file the document was loaded from
+}
+
+// Location returns the absolute path of the file the document was loaded
+// from, empty if it did not come from a file
+func (o *OpenAPI) Location() string {
+	return o.location
+}
+
+// SetLocation sets the path relative file refs are resolved against, for
+// documents that were built rather than loaded
+func (o *OpenAPI) SetLocation(path string) {
+	o.location = path
 }
 
 // Info provides metadata about the API
 type Info struct {
-	Title          string   `json:"title"`
-	Description    string   `json:"description,omitempty"`
-	TermsOfService string   `json:"termsOfService,omitempty"`
-	Version        string   `json:"version"`
-	Contact        *Contact `json:"contact,omitempty"`
-	License        *License `json:"license,omitempty"`
+	Title          string     `json:"title"`
+	Summary        string     `json:"summary,omitempty"`
+	Description    string     `json:"description,omitempty"`
+	TermsOfService string     `json:"termsOfService,omitempty"`
+	Version        string     `json:"version"`
+	Contact        *Contact   `json:"contact,omitempty"`
+	License        *License   `json:"license,omitempty"`
+	Extensions     Extensions `json:"-"`
 }
 
 // Contact represents contact information
 type Contact struct {
-	Name  string `json:"name,omitempty"`
-	URL   string `json:"url,omitempty"`
-	Email string `json:"email,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	URL        string     `json:"url,omitempty"`
+	Email      string     `json:"email,omitempty"`
+	Extensions Extensions `json:"-"`
 }
 
 // License represents license information
 type License struct {
-	Name string `json:"name"`
-	URL  string `json:"url,omitempty"`
+	Name       string     `json:"name"`
+	Identifier string     `json:"identifier,omitempty"`
+	URL        string     `json:"url,omitempty"`
+	Extensions Extensions `json:"-"`
 }
 
 // PathItem represents an endpoint and its operations
 type PathItem struct {
+	Ref         string      `json:"$ref,omitempty"`
 	Summary     string      `json:"summary,omitempty"`
 	Description string      `json:"description,omitempty"`
 	Get         *Operation  `json:"get,omitempty"`
@@ -100,62 +78,168 @@ type PathItem struct {
 	Head        *Operation  `json:"head,omitempty"`
 	Patch       *Operation  `json:"patch,omitempty"`
 	Trace       *Operation  `json:"trace,omitempty"`
+	Servers     []Server    `json:"servers,omitempty"`
 	Parameters  []Parameter `json:"parameters,omitempty"`
+	Extensions  Extensions  `json:"-"`
 }
 
 // Operation represents a single API operation on a path
 type Operation struct {
-	Tags        []string            `json:"tags,omitempty"`
-	Summary     string              `json:"summary,omitempty"`
-	Description string              `json:"description,omitempty"`
-	OperationID string              `json:"operationId,omitempty"`
-	Parameters  []Parameter         `json:"parameters,omitempty"`
-	RequestBody *RequestBody        `json:"requestBody,omitempty"`
-	Responses   map[string]Response `json:"responses"`
+	Tags                []string               `json:"tags,omitempty"`
+	Summary             string                 `json:"summary,omitempty"`
+	Description         string                 `json:"description,omitempty"`
+	ExternalDocs        *ExternalDocumentation `json:"externalDocs,omitempty"`
+	OperationID         string                 `json:"operationId,omitempty"`
+	Parameters          []Parameter            `json:"parameters,omitempty"`
+	RequestBody         *RequestBody           `json:"requestBody,omitempty"`
+	Responses           map[string]Response    `json:"responses,omitempty"`
+	ResponsesExtensions Extensions             `json:"-"` // x- extensions of the responses object
+	Callbacks           map[string]Callback    `json:"callbacks,omitempty"`
+	Deprecated          bool                   `json:"deprecated,omitempty"`
+	Security            []map[string][]string  `json:"security,omitempty"` // An empty, non-nil list removes the top-level security
+	Servers             []Server               `json:"servers,omitempty"`
+	Extensions          Extensions             `json:"-"`
 }
 
 // Parameter describes a single operation parameter
 type Parameter struct {
-	Name        string      `json:"name"`
-	In          string      `json:"in"`
-	Description string      `json:"description,omitempty"`
-	Required    bool        `json:"required,omitempty"`
-	Schema      interface{} `json:"schema,omitempty"` // JSON Schema object
+	Ref             string               `json:"$ref,omitempty"`
+	Name            string               `json:"name,omitempty"`
+	In              string               `json:"in,omitempty"`
+	Description     string               `json:"description,omitempty"`
+	Required        bool                 `json:"required,omitempty"`
+	Deprecated      bool                 `json:"deprecated,omitempty"`
+	AllowEmptyValue bool                 `json:"allowEmptyValue,omitempty"`
+	Style           string               `json:"style,omitempty"`
+	Explode         *bool                `json:"explode,omitempty"`
+	AllowReserved   bool                 `json:"allowReserved,omitempty"`
+	Schema          *Schema              `json:"schema,omitempty"`
+	Example         json.RawMessage      `json:"example,omitempty"`
+	Examples        map[string]Example   `json:"examples,omitempty"`
+	Content         map[string]MediaType `json:"content,omitempty"`
+	Extensions      Extensions           `json:"-"`
 }
 
 // RequestBody represents the body of a request
 type RequestBody struct {
+	Ref         string               `json:"$ref,omitempty"`
 	Description string               `json:"description,omitempty"`
-	Content     map[string]MediaType `json:"content"`
+	Content     map[string]MediaType `json:"content,omitempty"`
 	Required    bool                 `json:"required,omitempty"`
+	Extensions  Extensions           `json:"-"`
 }
 
 // Response represents a response from an API operation
 type Response struct {
-	Description string               `json:"description"`
-	Content     map[string]MediaType `json:"content,omitempty"`
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
 	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+	Links       map[string]Link      `json:"links,omitempty"`
+	Extensions  Extensions           `json:"-"`
 }
 
 // MediaType represents a media type object
 type MediaType struct {
-	Schema   interface{}        `json:"schema,omitempty"` // JSON Schema object
-	Example  interface{}        `json:"example,omitempty"`
-	Examples map[string]Example `json:"examples,omitempty"`
+	Schema     *Schema             `json:"schema,omitempty"`
+	Example    json.RawMessage     `json:"example,omitempty"`
+	Examples   map[string]Example  `json:"examples,omitempty"`
+	Encoding   map[string]Encoding `json:"encoding,omitempty"`
+	Extensions Extensions          `json:"-"`
+}
+
+// Encoding describes how a single property of a request body is serialized
+type Encoding struct {
+	ContentType   string            `json:"contentType,omitempty"`
+	Headers       map[string]Header `json:"headers,omitempty"`
+	Style         string            `json:"style,omitempty"`
+	Explode       *bool             `json:"explode,omitempty"`
+	AllowReserved bool              `json:"allowReserved,omitempty"`
+	Extensions    Extensions        `json:"-"`
 }
 
 // Header represents a single header in a response
 type Header struct {
-	Description string      `json:"description,omitempty"`
-	Schema      interface{} `json:"schema,omitempty"` // JSON Schema object
+	Ref             string               `json:"$ref,omitempty"`
+	Description     string               `json:"description,omitempty"`
+	Required        bool                 `json:"required,omitempty"`
+	Deprecated      bool                 `json:"deprecated,omitempty"`
+	AllowEmptyValue bool                 `json:"allowEmptyValue,omitempty"`
+	Style           string               `json:"style,omitempty"`
+	Explode         *bool                `json:"explode,omitempty"`
+	AllowReserved   bool                 `json:"allowReserved,omitempty"`
+	Schema          *Schema              `json:"schema,omitempty"`
+	Example         json.RawMessage      `json:"example,omitempty"`
+	Examples        map[string]Example   `json:"examples,omitempty"`
+	Content         map[string]MediaType `json:"content,omitempty"`
+	Extensions      Extensions           `json:"-"`
+}
+
+// Link describes how values of a response can be used to call another operation
+type Link struct {
+	Ref          string                 `json:"$ref,omitempty"`
+	OperationRef string                 `json:"operationRef,omitempty"`
+	OperationID  string                 `json:"operationId,omitempty"`
+	Parameters   map[string]interface{} `json:"parameters,omitempty"`
+	RequestBody  json.RawMessage        `json:"requestBody,omitempty"`
+	Description  string                 `json:"description,omitempty"`
+	Server       *Server                `json:"server,omitempty"`
+	Extensions   Extensions             `json:"-"`
+}
+
+// Callback maps runtime expressions to the requests the API may send back,
+// it is either a $ref or a set of path items
+type Callback struct {
+	Ref        string
+	PathItems  map[string]PathItem
+	Extensions Extensions
 }
 
 // Components contains reusable objects
 type Components struct {
-	Schemas       map[string]interface{} `json:"schemas,omitempty"` // JSON Schema objects
-	Responses     map[string]Response    `json:"responses,omitempty"`
-	Parameters    map[string]Parameter   `json:"parameters,omitempty"`
-	RequestBodies map[string]RequestBody `json:"requestBodies,omitempty"`
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	Responses       map[string]Response       `json:"responses,omitempty"`
+	Parameters      map[string]Parameter      `json:"parameters,omitempty"`
+	Examples        map[string]Example        `json:"examples,omitempty"`
+	RequestBodies   map[string]RequestBody    `json:"requestBodies,omitempty"`
+	Headers         map[string]Header         `json:"headers,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+	Links           map[string]Link           `json:"links,omitempty"`
+	Callbacks       map[string]Callback       `json:"callbacks,omitempty"`
+	PathItems       map[string]PathItem       `json:"pathItems,omitempty"`
+	Extensions      Extensions                `json:"-"`
+}
+
+// SecurityScheme defines a security scheme that can be used by the operations
+type SecurityScheme struct {
+	Ref              string      `json:"$ref,omitempty"`
+	Type             string      `json:"type,omitempty"`
+	Description      string      `json:"description,omitempty"`
+	Name             string      `json:"name,omitempty"`
+	In               string      `json:"in,omitempty"`
+	Scheme           string      `json:"scheme,omitempty"`
+	BearerFormat     string      `json:"bearerFormat,omitempty"`
+	Flows            *OAuthFlows `json:"flows,omitempty"`
+	OpenIDConnectURL string      `json:"openIdConnectUrl,omitempty"`
+	Extensions       Extensions  `json:"-"`
+}
+
+// OAuthFlows configures the supported OAuth flows
+type OAuthFlows struct {
+	Implicit          *OAuthFlow `json:"implicit,omitempty"`
+	Password          *OAuthFlow `json:"password,omitempty"`
+	ClientCredentials *OAuthFlow `json:"clientCredentials,omitempty"`
+	AuthorizationCode *OAuthFlow `json:"authorizationCode,omitempty"`
+	Extensions        Extensions `json:"-"`
+}
+
+// OAuthFlow represents the configuration of a single OAuth flow
+type OAuthFlow struct {
+	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty"`
+	RefreshURL       string            `json:"refreshUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes"`
+	Extensions       Extensions        `json:"-"`
 }
 
 // Server represents a server
@@ -163,13 +247,15 @@ type Server struct {
 	URL         string                    `json:"url"`
 	Description string                    `json:"description,omitempty"`
 	Variables   map[string]ServerVariable `json:"variables,omitempty"`
+	Extensions  Extensions                `json:"-"`
 }
 
 // ServerVariable represents a server variable for server URL template substitution
 type ServerVariable struct {
-	Enum        []string `json:"enum,omitempty"`
-	Default     string   `json:"default"`
-	Description string   `json:"description,omitempty"`
+	Enum        []string   `json:"enum,omitempty"`
+	Default     string     `json:"default"`
+	Description string     `json:"description,omitempty"`
+	Extensions  Extensions `json:"-"`
 }
 
 // Tag represents a tag for API operations
@@ -177,18 +263,22 @@ type Tag struct {
 	Name         string                 `json:"name"`
 	Description  string                 `json:"description,omitempty"`
 	ExternalDocs *ExternalDocumentation `json:"externalDocs,omitempty"`
+	Extensions   Extensions             `json:"-"`
 }
 
 // ExternalDocumentation represents external documentation
 type ExternalDocumentation struct {
-	Description string `json:"description,omitempty"`
-	URL         string `json:"url"`
+	Description string     `json:"description,omitempty"`
+	URL         string     `json:"url"`
+	Extensions  Extensions `json:"-"`
 }
 
 // Example represents an example object
 type Example struct {
-	Summary       string      `json:"summary,omitempty"`
-	Description   string      `json:"description,omitempty"`
-	Value         interface{} `json:"value,omitempty"`
-	ExternalValue string      `json:"externalValue,omitempty"`
+	Ref           string          `json:"$ref,omitempty"`
+	Summary       string          `json:"summary,omitempty"`
+	Description   string          `json:"description,omitempty"`
+	Value         json.RawMessage `json:"value,omitempty"`
+	ExternalValue string          `json:"externalValue,omitempty"`
+	Extensions    Extensions      `json:"-"`
 }
@@ -0,0 +1,217 @@
+package apiview
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Request is an HTTP request as it is edited before it is sent
+type Request struct {
+	Method      HTTPMethod
+	URL         string // May contain {name} placeholders filled from the path params
+	Params      []Param
+	Headers     []Header
+	Body        string
+	ContentType string
+	Auth        Auth
+}
+
+// Param is a path or query parameter of a request
+type Param struct {
+	Key     string
+	Value   string
+	Enabled bool
+	Path    bool // Replaces {Key} in the URL instead of being added to the query
+}
+
+// AuthType selects how a request authenticates
+type AuthType int32
+
+const (
+	AuthNone AuthType = iota
+	AuthBearer
+	AuthBasic
+	AuthAPIKey
+)
+
+var authTypeName = map[AuthType]string{
+	AuthNone:   "none",
+	AuthBearer: "bearer",
+	AuthBasic:  "basic",
+	AuthAPIKey: "api key",
+}
+
+func (a AuthType) String() string {
+	return authTypeName[a]
+}
+
+// AuthTypes lists all auth types in the order they are cycled through
+var AuthTypes = []AuthType{AuthNone, AuthBearer, AuthBasic, AuthAPIKey}
+
+// Auth holds the credentials of a request, only the fields of Type are used
+type Auth struct {
+	Type     AuthType
+	Token    string // Bearer
+	Username string // Basic
+	Password string // Basic
+	Name     string // API key header or query param name
+	Value    string // API key
+	InQuery  bool   // API key is sent as query param instead of a header
+}
+
+// NewRequest prefills a request from an endpoint with the examples of its
+// parameters and the first media type of its body
+func NewRequest(e Endpoint) Request {
+	r := Request{
+		Method: e.Method,
+		URL:    e.URL(),
+	}
+
+	for _, p := range e.Parameters {
+		switch p.In {
+		case "path", "query":
+			r.Params = append(r.Params, Param{
+				Key:     p.Name,
+				Value:   ValueString(p.Example),
+				Enabled: p.Required,
+				Path:    p.In == "path",
+			})
+		}
+	}
+
+	r.Headers = append(r.Headers, e.Headers...)
+
+	if e.RequestBody != nil && len(e.RequestBody.MediaTypes) > 0 {
+		mt := e.RequestBody.MediaTypes[0]
+		r.ContentType = mt.ContentType
+		r.Body = BodyExample(mt)
+	}
+
+	return r
+}
+
+// SetHeader replaces the header rows named key, in any case, with a single
+// enabled one
+func (r *Request) SetHeader(key, value string) {
+	headers := r.Headers[:0:0]
+	for _, h := range r.Headers {
+		if !strings.EqualFold(h.Key, key) {
+			headers = append(headers, h)
+		}
+	}
+	r.Headers = append(headers, Header{Key: key, Value: value, Enabled: true})
+}
+
+// SetParam sets the value of the param key and enables it, unknown params are
+// added to the query
+func (r *Request) SetParam(key, value string) {
+	for i := range r.Params {
+		if r.Params[i].Key == key {
+			r.Params[i].Value = value
+			r.Params[i].Enabled = true
+			return
+		}
+	}
+	r.Params = append(r.Params, Param{Key: key, Value: value, Enabled: true})
+}
+
+// BodyExample renders the example of a media type as a request body, JSON
+// is indented
+func BodyExample(mt MediaType) string {
+	switch v := mt.Example.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+
+	data, err := json.MarshalIndent(mt.Example, "", "  ")
+	if err != nil {
+		return ValueString(mt.Example)
+	}
+	return string(data)
+}
+
+// BuildURL fills in the path params and appends the enabled query params in
+// their order, the query already in the URL is kept as typed
+func (r Request) BuildURL() (string, error) {
+	raw := r.URL
+	for _, p := range r.Params {
+		if p.Path {
+			raw = strings.ReplaceAll(raw, "{"+p.Key+"}", url.PathEscape(p.Value))
+		}
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+
+	var query []string
+	if u.RawQuery != "" {
+		query = append(query, u.RawQuery)
+	}
+	for _, p := range r.Params {
+		if p.Enabled && !p.Path && p.Key != "" {
+			query = append(query, url.QueryEscape(p.Key)+"="+url.QueryEscape(p.Value))
+		}
+	}
+	if r.Auth.Type == AuthAPIKey && r.Auth.InQuery && r.Auth.Name != "" {
+		query = append(query, url.QueryEscape(r.Auth.Name)+"="+url.QueryEscape(r.Auth.Value))
+	}
+	u.RawQuery = strings.Join(query, "&")
+
+	return u.String(), nil
+}
+
+// HTTPRequest builds the request to send, enabled headers are added as given
+// so a key may repeat. A Host header sets the host sent, net/http ignores it
+// among the headers. The Content-Type header defaults to ContentType if there
+// is a body and no header sets it.
+func (r Request) HTTPRequest(ctx context.Context) (*http.Request, error) {
+	u, err := r.BuildURL()
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if r.Body != "" {
+		body = strings.NewReader(r.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(r.Method.String()), u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range r.Headers {
+		switch {
+		case !h.Enabled || h.Key == "":
+		case strings.EqualFold(h.Key, "Host"):
+			req.Host = h.Value
+		default:
+			req.Header.Add(h.Key, h.Value)
+		}
+	}
+	if r.Body != "" && r.ContentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", r.ContentType)
+	}
+
+	switch r.Auth.Type {
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+r.Auth.Token)
+	case AuthBasic:
+		req.SetBasicAuth(r.Auth.Username, r.Auth.Password)
+	case AuthAPIKey:
+		if !r.Auth.InQuery && r.Auth.Name != "" {
+			req.Header.Set(r.Auth.Name, r.Auth.Value)
+		}
+	}
+
+	return req, nil
+}
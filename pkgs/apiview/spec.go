@@ -0,0 +1,80 @@
+package apiview
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bata94/reqlab/pkgs/apiview/openapi"
+	"github.com/bata94/reqlab/pkgs/apiview/swagger2"
+)
+
+// LoadSpec reads an OpenAPI 3.x or Swagger 2.0 document from path, "-"
+// reads from stdin. Swagger documents are upgraded to OpenAPI 3.
+func LoadSpec(path string) (*openapi.OpenAPI, error) {
+	var (
+		data   []byte
+		err    error
+		source = path
+	)
+
+	if path == openapi.StdinPath {
+		source = "<stdin>"
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := ParseSpec(data)
+	if err != nil {
+		var decErr *openapi.DecodeError
+		if errors.As(err, &decErr) {
+			decErr.Source = source
+		}
+		return nil, err
+	}
+
+	if path != openapi.StdinPath {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		doc.SetLocation(abs)
+	}
+
+	return doc, nil
+}
+
+// ParseSpec decodes an OpenAPI 3.x or Swagger 2.0 document from JSON or YAML
+func ParseSpec(data []byte) (*openapi.OpenAPI, error) {
+	var probe struct {
+		Swagger string `json:"swagger"`
+		OpenAPI string `json:"openapi"`
+	}
+	if err := openapi.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasPrefix(probe.OpenAPI, "3."):
+		return openapi.Parse(data)
+	case strings.HasPrefix(probe.Swagger, "2."):
+		doc, err := swagger2.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		return swagger2.ToOpenAPI(doc)
+	case probe.OpenAPI != "":
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", probe.OpenAPI)
+	case probe.Swagger != "":
+		return nil, fmt.Errorf("unsupported Swagger version %q", probe.Swagger)
+	}
+
+	return nil, errors.New("neither an OpenAPI nor a Swagger document")
+}
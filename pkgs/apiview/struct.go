@@ -1,5 +1,14 @@
 package apiview
 
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/bata94/reqlab/pkgs/apiview/openapi"
+)
+
 type HTTPMethod int32
 
 const (
@@ -30,10 +39,66 @@ func (h HTTPMethod) String() string {
 	return httpMethodName[h]
 }
 
+// HTTPMethods lists all methods in the order operations are shown
+var HTTPMethods = []HTTPMethod{GET, POST, PUT, DELETE, PATCH, HEAD, OPTIONS, CONNECT, TRACE}
+
+// ParseHTTPMethod parses a method name in any case, e.g. "get" or "POST"
+func ParseHTTPMethod(s string) (HTTPMethod, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for method, n := range httpMethodName {
+		if n == name {
+			return method, nil
+		}
+	}
+	return GET, fmt.Errorf("unknown HTTP method %q", s)
+}
+
 type Endpoint struct {
 	Path    string
 	Method  HTTPMethod
 	Headers []Header
+
+	OperationID string
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	Servers     []string // Server URLs with their variables set to the defaults
+	Parameters  []Parameter
+	RequestBody *RequestBody
+	Responses   []Response
+}
+
+// Parameter is a path, query, header or cookie parameter of an endpoint
+type Parameter struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Deprecated  bool
+	Schema      *openapi.Schema
+	Example     interface{}
+}
+
+// RequestBody lists the media types an endpoint accepts
+type RequestBody struct {
+	Description string
+	Required    bool
+	MediaTypes  []MediaType
+}
+
+// MediaType is a single content type of a request or response body
+type MediaType struct {
+	ContentType string
+	Schema      *openapi.Schema
+	Example     interface{}
+}
+
+// Response is one of the responses an endpoint documents
+type Response struct {
+	Status      string // Status code, a range like "2XX" or "default"
+	Description string
+	MediaTypes  []MediaType
 }
 
 type Header struct {
@@ -41,3 +106,25 @@ type Header struct {
 	Value   string
 	Enabled bool
 }
+
+// URL joins the first server of the endpoint with its path
+func (e Endpoint) URL() string {
+	server := "/"
+	if len(e.Servers) > 0 {
+		server = e.Servers[0]
+	}
+	return strings.TrimSuffix(server, "/") + e.Path
+}
+
+// ErrRelativeServer is returned for endpoints that can't be sent, their spec
+// has relative servers only and was not loaded from a URL to resolve them
+var ErrRelativeServer = errors.New("the server of the spec is relative")
+
+// CheckServer returns ErrRelativeServer if the URL of e has no scheme or host
+func (e Endpoint) CheckServer() error {
+	u, err := url.Parse(e.URL())
+	if err == nil && u.Scheme != "" && u.Host != "" {
+		return nil
+	}
+	return fmt.Errorf("%w, %s %s would be sent to %s", ErrRelativeServer, strings.ToUpper(e.Method.String()), e.Path, e.URL())
+}
@@ -0,0 +1,580 @@
+package swagger2
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bata94/reqlab/pkgs/apiview/openapi"
+)
+
+// OpenAPIVersion is the version documents are upgraded to
+const OpenAPIVersion = "3.0.3"
+
+const (
+	defaultMediaType   = "application/json"
+	formURLEncodedType = "application/x-www-form-urlencoded"
+	multipartFormType  = "multipart/form-data"
+)
+
+// Parse decodes a Swagger 2.0 document from JSON or YAML data
+func Parse(data []byte) (*Swagger, error) {
+	doc := &Swagger{}
+	if err := openapi.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// ToOpenAPI upgrades a Swagger 2.0 document to OpenAPI 3.0. Servers are
+// built from host, basePath and schemes, body and formData parameters
+// become request bodies and definitions move to the components.
+func ToOpenAPI(doc *Swagger) (*openapi.OpenAPI, error) {
+	c := converter{doc: doc}
+	return c.convert()
+}
+
+type converter struct {
+	doc *Swagger
+}
+
+func (c *converter) convert() (*openapi.OpenAPI, error) {
+	out := &openapi.OpenAPI{
+		OpenAPI:         OpenAPIVersion,
+		Info:            c.doc.Info,
+		Servers:         servers(c.doc.Schemes, c.doc.Host, c.doc.BasePath),
+		Tags:            c.doc.Tags,
+		Security:        c.doc.Security,
+		ExternalDocs:    c.doc.ExternalDocs,
+		Paths:           make(map[string]openapi.PathItem, len(c.doc.Paths)),
+		PathsExtensions: c.doc.PathsExtensions,
+		Extensions:      c.doc.Extensions,
+	}
+
+	for path, item := range c.doc.Paths {
+		converted, err := c.pathItem(item)
+		if err != nil {
+			return nil, fmt.Errorf("path %s: %w", path, err)
+		}
+		out.Paths[path] = converted
+	}
+
+	components, err := c.components()
+	if err != nil {
+		return nil, err
+	}
+	out.Components = components
+
+	return out, nil
+}
+
+func (c *converter) components() (*openapi.Components, error) {
+	comp := &openapi.Components{}
+
+	if len(c.doc.Definitions) > 0 {
+		comp.Schemas = make(map[string]*openapi.Schema, len(c.doc.Definitions))
+		for name, schema := range c.doc.Definitions {
+			comp.Schemas[name] = convertSchema(schema)
+		}
+	}
+
+	for name, param := range c.doc.Parameters {
+		switch param.In {
+		case "body":
+			if comp.RequestBodies == nil {
+				comp.RequestBodies = map[string]openapi.RequestBody{}
+			}
+			comp.RequestBodies[name] = bodyParameter(param, c.doc.Consumes)
+		case "formData":
+			// Form fields have no component equivalent, they are inlined
+			// into the request bodies that reference them
+		default:
+			if comp.Parameters == nil {
+				comp.Parameters = map[string]openapi.Parameter{}
+			}
+			comp.Parameters[name] = parameter(param)
+		}
+	}
+
+	if len(c.doc.Responses) > 0 {
+		comp.Responses = make(map[string]openapi.Response, len(c.doc.Responses))
+		for name, resp := range c.doc.Responses {
+			comp.Responses[name] = response(resp, c.doc.Produces)
+		}
+	}
+
+	if len(c.doc.SecurityDefinitions) > 0 {
+		comp.SecuritySchemes = make(map[string]openapi.SecurityScheme, len(c.doc.SecurityDefinitions))
+		for name, scheme := range c.doc.SecurityDefinitions {
+			converted, err := securityScheme(scheme)
+			if err != nil {
+				return nil, fmt.Errorf("security definition %s: %w", name, err)
+			}
+			comp.SecuritySchemes[name] = converted
+		}
+	}
+
+	if comp.Schemas == nil && comp.RequestBodies == nil && comp.Parameters == nil &&
+		comp.Responses == nil && comp.SecuritySchemes == nil {
+		return nil, nil
+	}
+
+	return comp, nil
+}
+
+func (c *converter) pathItem(item PathItem) (openapi.PathItem, error) {
+	out := openapi.PathItem{Ref: item.Ref, Extensions: item.Extensions}
+
+	// Body and form parameters declared on the path apply to every
+	// operation, in OpenAPI 3 they have to live on each request body
+	var bodyParams []Parameter
+	for _, p := range item.Parameters {
+		resolved, err := c.lookupParameter(p)
+		if err != nil {
+			return out, err
+		}
+		if resolved.In == "body" || resolved.In == "formData" {
+			bodyParams = append(bodyParams, p)
+			continue
+		}
+		out.Parameters = append(out.Parameters, c.parameterRef(p))
+	}
+
+	ops := []struct {
+		src *Operation
+		dst **openapi.Operation
+	}{
+		{item.Get, &out.Get},
+		{item.Put, &out.Put},
+		{item.Post, &out.Post},
+		{item.Delete, &out.Delete},
+		{item.Options, &out.Options},
+		{item.Head, &out.Head},
+		{item.Patch, &out.Patch},
+	}
+	for _, op := range ops {
+		if op.src == nil {
+			continue
+		}
+		converted, err := c.operation(op.src, bodyParams)
+		if err != nil {
+			return out, err
+		}
+		*op.dst = converted
+	}
+
+	return out, nil
+}
+
+func (c *converter) operation(op *Operation, pathBodyParams []Parameter) (*openapi.Operation, error) {
+	out := &openapi.Operation{
+		Tags:                op.Tags,
+		Summary:             op.Summary,
+		Description:         op.Description,
+		ExternalDocs:        op.ExternalDocs,
+		OperationID:         op.OperationID,
+		Deprecated:          op.Deprecated,
+		Security:            op.Security,
+		ResponsesExtensions: op.ResponsesExtensions,
+		Extensions:          op.Extensions,
+	}
+
+	if len(op.Schemes) > 0 && !sameStrings(op.Schemes, c.doc.Schemes) {
+		out.Servers = servers(op.Schemes, c.doc.Host, c.doc.BasePath)
+	}
+
+	consumes := firstNonEmpty(op.Consumes, c.doc.Consumes, []string{defaultMediaType})
+	produces := firstNonEmpty(op.Produces, c.doc.Produces, []string{defaultMediaType})
+
+	var bodyParams []Parameter
+	var formParams []Parameter
+	overridden := map[string]bool{}
+	for _, p := range op.Parameters {
+		resolved, err := c.lookupParameter(p)
+		if err != nil {
+			return nil, err
+		}
+		overridden[resolved.In+":"+resolved.Name] = true
+
+		switch resolved.In {
+		case "body":
+			bodyParams = append(bodyParams, p)
+		case "formData":
+			formParams = append(formParams, resolved)
+		default:
+			out.Parameters = append(out.Parameters, c.parameterRef(p))
+		}
+	}
+	for _, p := range pathBodyParams {
+		resolved, err := c.lookupParameter(p)
+		if err != nil {
+			return nil, err
+		}
+		if overridden[resolved.In+":"+resolved.Name] {
+			continue
+		}
+		if resolved.In == "body" {
+			bodyParams = append(bodyParams, p)
+		} else {
+			formParams = append(formParams, resolved)
+		}
+	}
+
+	switch {
+	case len(bodyParams) > 0:
+		body := bodyParams[0]
+		if name, ok := strings.CutPrefix(body.Ref, "#/parameters/"); ok {
+			out.RequestBody = &openapi.RequestBody{Ref: "#/components/requestBodies/" + name}
+		} else {
+			rb := bodyParameter(body, consumes)
+			out.RequestBody = &rb
+		}
+	case len(formParams) > 0:
+		out.RequestBody = formBody(formParams, consumes)
+	}
+
+	if len(op.Responses) > 0 {
+		out.Responses = make(map[string]openapi.Response, len(op.Responses))
+		for code, resp := range op.Responses {
+			if name, ok := strings.CutPrefix(resp.Ref, "#/responses/"); ok {
+				out.Responses[code] = openapi.Response{Ref: "#/components/responses/" + name}
+				continue
+			}
+			out.Responses[code] = response(resp, produces)
+		}
+	}
+
+	return out, nil
+}
+
+// lookupParameter returns the global parameter p refers to, or p itself
+func (c *converter) lookupParameter(p Parameter) (Parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+
+	name, ok := strings.CutPrefix(p.Ref, "#/parameters/")
+	if !ok {
+		// Refs into other files are kept as they are, they can only be
+		// followed once the document is resolved
+		return p, nil
+	}
+
+	global, ok := c.doc.Parameters[name]
+	if !ok {
+		return p, fmt.Errorf("unknown parameter %s", p.Ref)
+	}
+	return global, nil
+}
+
+func (c *converter) parameterRef(p Parameter) openapi.Parameter {
+	if name, ok := strings.CutPrefix(p.Ref, "#/parameters/"); ok {
+		return openapi.Parameter{Ref: "#/components/parameters/" + name}
+	}
+	if p.Ref != "" {
+		return openapi.Parameter{Ref: p.Ref}
+	}
+	return parameter(p)
+}
+
+func parameter(p Parameter) openapi.Parameter {
+	out := openapi.Parameter{
+		Name:            p.Name,
+		In:              p.In,
+		Description:     p.Description,
+		Required:        p.Required,
+		AllowEmptyValue: p.AllowEmptyValue,
+		Schema:          simpleSchema(p.SimpleSchema),
+		Extensions:      p.Extensions,
+	}
+
+	if p.Type == "array" {
+		out.Style, out.Explode = collectionStyle(p.CollectionFormat, p.In)
+	}
+
+	return out
+}
+
+// collectionStyle maps a collectionFormat onto style and explode. tsv has
+// no equivalent and falls back to the defaults.
+func collectionStyle(format string, in string) (string, *bool) {
+	explode := func(b bool) *bool { return &b }
+
+	switch format {
+	case "", "csv":
+		if in == "query" || in == "cookie" {
+			return "form", explode(false)
+		}
+		return "simple", nil
+	case "ssv":
+		return "spaceDelimited", explode(false)
+	case "pipes":
+		return "pipeDelimited", explode(false)
+	case "multi":
+		return "form", explode(true)
+	}
+	return "", nil
+}
+
+func bodyParameter(p Parameter, consumes []string) openapi.RequestBody {
+	out := openapi.RequestBody{
+		Description: p.Description,
+		Required:    p.Required,
+		Content:     make(map[string]openapi.MediaType, len(consumes)),
+		Extensions:  p.Extensions,
+	}
+
+	schema := convertSchema(p.Schema)
+	for _, mime := range consumes {
+		out.Content[mime] = openapi.MediaType{Schema: schema}
+	}
+
+	return out
+}
+
+// formBody merges formData parameters into a single object schema
+func formBody(params []Parameter, consumes []string) *openapi.RequestBody {
+	schema := &openapi.Schema{
+		Type:       openapi.SchemaType{"object"},
+		Properties: make(map[string]*openapi.Schema, len(params)),
+	}
+	hasFile := false
+
+	for _, p := range params {
+		prop := simpleSchema(p.SimpleSchema)
+		prop.Description = p.Description
+		prop.Extensions = p.Extensions
+		schema.Properties[p.Name] = prop
+
+		if p.Required {
+			schema.Required = append(schema.Required, p.Name)
+		}
+		if p.Type == "file" {
+			hasFile = true
+		}
+	}
+
+	var mimes []string
+	for _, mime := range consumes {
+		if mime == formURLEncodedType || mime == multipartFormType {
+			mimes = append(mimes, mime)
+		}
+	}
+	if len(mimes) == 0 {
+		mimes = []string{formURLEncodedType}
+		if hasFile {
+			mimes = []string{multipartFormType}
+		}
+	}
+
+	body := &openapi.RequestBody{
+		Content:  make(map[string]openapi.MediaType, len(mimes)),
+		Required: len(schema.Required) > 0,
+	}
+	for _, mime := range mimes {
+		body.Content[mime] = openapi.MediaType{Schema: schema}
+	}
+
+	return body
+}
+
+func response(r Response, produces []string) openapi.Response {
+	out := openapi.Response{
+		Ref:         r.Ref,
+		Description: r.Description,
+		Extensions:  r.Extensions,
+	}
+
+	if r.Schema != nil || len(r.Examples) > 0 {
+		out.Content = map[string]openapi.MediaType{}
+	}
+	if r.Schema != nil {
+		schema := convertSchema(r.Schema)
+		for _, mime := range produces {
+			out.Content[mime] = openapi.MediaType{Schema: schema}
+		}
+	}
+	for mime, example := range r.Examples {
+		media := out.Content[mime]
+		media.Example = example
+		if media.Schema == nil && r.Schema != nil {
+			media.Schema = convertSchema(r.Schema)
+		}
+		out.Content[mime] = media
+	}
+
+	if len(r.Headers) > 0 {
+		out.Headers = make(map[string]openapi.Header, len(r.Headers))
+		for name, h := range r.Headers {
+			out.Headers[name] = openapi.Header{
+				Description: h.Description,
+				Schema:      simpleSchema(h.SimpleSchema),
+				Extensions:  h.Extensions,
+			}
+		}
+	}
+
+	return out
+}
+
+func securityScheme(s SecurityScheme) (openapi.SecurityScheme, error) {
+	out := openapi.SecurityScheme{Description: s.Description, Extensions: s.Extensions}
+
+	switch s.Type {
+	case "basic":
+		out.Type = "http"
+		out.Scheme = "basic"
+	case "apiKey":
+		out.Type = "apiKey"
+		out.Name = s.Name
+		out.In = s.In
+	case "oauth2":
+		out.Type = "oauth2"
+		scopes := s.Scopes
+		if scopes == nil {
+			scopes = map[string]string{}
+		}
+
+		flow := &openapi.OAuthFlow{
+			AuthorizationURL: s.AuthorizationURL,
+			TokenURL:         s.TokenURL,
+			Scopes:           scopes,
+		}
+		out.Flows = &openapi.OAuthFlows{}
+		switch s.Flow {
+		case "implicit":
+			out.Flows.Implicit = flow
+		case "password":
+			out.Flows.Password = flow
+		case "application":
+			out.Flows.ClientCredentials = flow
+		case "accessCode":
+			out.Flows.AuthorizationCode = flow
+		default:
+			return out, fmt.Errorf("unknown oauth2 flow %q", s.Flow)
+		}
+	default:
+		return out, fmt.Errorf("unknown type %q", s.Type)
+	}
+
+	return out, nil
+}
+
+func servers(schemes []string, host string, basePath string) []openapi.Server {
+	if host == "" {
+		if basePath == "" {
+			return nil
+		}
+		return []openapi.Server{{URL: basePath}}
+	}
+
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+
+	out := make([]openapi.Server, 0, len(schemes))
+	for _, scheme := range schemes {
+		out = append(out, openapi.Server{URL: scheme + "://" + host + basePath})
+	}
+	return out
+}
+
+// simpleSchema turns the type fields of a non-body parameter or header into
+// a JSON Schema object
+func simpleSchema(s SimpleSchema) *openapi.Schema {
+	out := &openapi.Schema{
+		Format:      s.Format,
+		Default:     s.Default,
+		Maximum:     s.Maximum,
+		Minimum:     s.Minimum,
+		MaxLength:   s.MaxLength,
+		MinLength:   s.MinLength,
+		Pattern:     s.Pattern,
+		MaxItems:    s.MaxItems,
+		MinItems:    s.MinItems,
+		UniqueItems: s.UniqueItems,
+		Enum:        s.Enum,
+		MultipleOf:  s.MultipleOf,
+	}
+
+	switch s.Type {
+	case "":
+	case "file":
+		out.Type = openapi.SchemaType{"string"}
+		out.Format = "binary"
+	default:
+		out.Type = openapi.SchemaType{s.Type}
+	}
+	if s.ExclusiveMaximum {
+		out.ExclusiveMaximum = true
+	}
+	if s.ExclusiveMinimum {
+		out.ExclusiveMinimum = true
+	}
+	if s.Items != nil {
+		out.Items = simpleSchema(s.Items.SimpleSchema)
+		out.Items.Extensions = s.Items.Extensions
+	}
+
+	return out
+}
+
+// convertSchema copies a Swagger schema, pointing refs at the components
+// and rewriting the few keywords that changed in OpenAPI 3
+func convertSchema(schema *openapi.Schema) *openapi.Schema {
+	if schema == nil {
+		return nil
+	}
+
+	// Copy through JSON so the Swagger document itself stays untouched
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return schema
+	}
+	out := &openapi.Schema{}
+	if err := json.Unmarshal(data, out); err != nil {
+		return schema
+	}
+
+	openapi.WalkSchema(out, func(s *openapi.Schema) {
+		if name, ok := strings.CutPrefix(s.Ref, "#/definitions/"); ok {
+			s.Ref = "#/components/schemas/" + name
+		}
+		if s.Type.Is("file") {
+			s.Type = openapi.SchemaType{"string"}
+			s.Format = "binary"
+		}
+		if nullable, ok := s.Extensions["x-nullable"].(bool); ok {
+			s.Nullable = nullable
+			delete(s.Extensions, "x-nullable")
+		}
+	})
+
+	return out
+}
+
+func firstNonEmpty(lists ...[]string) []string {
+	for _, l := range lists {
+		if len(l) > 0 {
+			return l
+		}
+	}
+	return nil
+}
+
+func sameStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package swagger2
+
+import (
+	"encoding/json"
+
+	"github.com/bata94/reqlab/pkgs/apiview/openapi"
+)
+
+func (s *Swagger) UnmarshalJSON(data []byte) error {
+	type alias Swagger
+	return openapi.UnmarshalWithExtensions(data, (*alias)(s), &s.Extensions,
+		openapi.ExtensionContainer{Key: "paths", Target: &s.PathsExtensions})
+}
+
+func (s Swagger) MarshalJSON() ([]byte, error) {
+	type alias Swagger
+
+	paths, err := openapi.MarshalWithExtensions(s.Paths, s.PathsExtensions)
+	if err != nil {
+		return nil, err
+	}
+
+	return openapi.MarshalWithExtensions(struct {
+		alias
+		Paths json.RawMessage `json:"paths"`
+	}{alias(s), paths}, s.Extensions)
+}
+
+func (p *PathItem) UnmarshalJSON(data []byte) error {
+	type alias PathItem
+	return openapi.UnmarshalWithExtensions(data, (*alias)(p), &p.Extensions)
+}
+
+func (p PathItem) MarshalJSON() ([]byte, error) {
+	type alias PathItem
+	return openapi.MarshalWithExtensions(alias(p), p.Extensions)
+}
+
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	type alias Operation
+	return openapi.UnmarshalWithExtensions(data, (*alias)(o), &o.Extensions,
+		openapi.ExtensionContainer{Key: "responses", Target: &o.ResponsesExtensions})
+}
+
+func (o Operation) MarshalJSON() ([]byte, error) {
+	type alias Operation
+
+	responses, err := openapi.MarshalWithExtensions(o.Responses, o.ResponsesExtensions)
+	if err != nil {
+		return nil, err
+	}
+
+	return openapi.MarshalWithExtensions(struct {
+		alias
+		Responses json.RawMessage `json:"responses"`
+	}{alias(o), responses}, o.Extensions)
+}
+
+func (p *Parameter) UnmarshalJSON(data []byte) error {
+	type alias Parameter
+	return openapi.UnmarshalWithExtensions(data, (*alias)(p), &p.Extensions)
+}
+
+func (p Parameter) MarshalJSON() ([]byte, error) {
+	type alias Parameter
+	return openapi.MarshalWithExtensions(alias(p), p.Extensions)
+}
+
+func (i *Items) UnmarshalJSON(data []byte) error {
+	type alias Items
+	return openapi.UnmarshalWithExtensions(data, (*alias)(i), &i.Extensions)
+}
+
+func (i Items) MarshalJSON() ([]byte, error) {
+	type alias Items
+	return openapi.MarshalWithExtensions(alias(i), i.Extensions)
+}
+
+func (r *Response) UnmarshalJSON(data []byte) error {
+	type alias Response
+	return openapi.UnmarshalWithExtensions(data, (*alias)(r), &r.Extensions)
+}
+
+func (r Response) MarshalJSON() ([]byte, error) {
+	type alias Response
+	return openapi.MarshalWithExtensions(alias(r), r.Extensions)
+}
+
+func (h *Header) UnmarshalJSON(data []byte) error {
+	type alias Header
+	return openapi.UnmarshalWithExtensions(data, (*alias)(h), &h.Extensions)
+}
+
+func (h Header) MarshalJSON() ([]byte, error) {
+	type alias Header
+	return openapi.MarshalWithExtensions(alias(h), h.Extensions)
+}
+
+func (s *SecurityScheme) UnmarshalJSON(data []byte) error {
+	type alias SecurityScheme
+	return openapi.UnmarshalWithExtensions(data, (*alias)(s), &s.Extensions)
+}
+
+func (s SecurityScheme) MarshalJSON() ([]byte, error) {
+	type alias SecurityScheme
+	return openapi.MarshalWithExtensions(alias(s), s.Extensions)
+}
@@ -0,0 +1,134 @@
+package swagger2
+
+import (
+	"encoding/json"
+
+	"github.com/bata94/reqlab/pkgs/apiview/openapi"
+)
+
+// Swagger represents the root of a Swagger 2.0 document
+type Swagger struct {
+	Swagger             string                         `json:"swagger"`
+	Info                openapi.Info                   `json:"info"`
+	Host                string                         `json:"host,omitempty"`
+	BasePath            string                         `json:"basePath,omitempty"`
+	Schemes             []string                       `json:"schemes,omitempty"`
+	Consumes            []string                       `json:"consumes,omitempty"`
+	Produces            []string                       `json:"produces,omitempty"`
+	Paths               map[string]PathItem            `json:"paths"`
+	PathsExtensions     openapi.Extensions             `json:"-"` // x- extensions of the paths object
+	Definitions         map[string]*openapi.Schema     `json:"definitions,omitempty"`
+	Parameters          map[string]Parameter           `json:"parameters,omitempty"`
+	Responses           map[string]Response            `json:"responses,omitempty"`
+	SecurityDefinitions map[string]SecurityScheme      `json:"securityDefinitions,omitempty"`
+	Security            []map[string][]string          `json:"security,omitempty"`
+	Tags                []openapi.Tag                  `json:"tags,omitempty"`
+	ExternalDocs        *openapi.ExternalDocumentation `json:"externalDocs,omitempty"`
+	Extensions          openapi.Extensions             `json:"-"`
+}
+
+// PathItem represents an endpoint and its operations
+type PathItem struct {
+	Ref        string             `json:"$ref,omitempty"`
+	Get        *Operation         `json:"get,omitempty"`
+	Put        *Operation         `json:"put,omitempty"`
+	Post       *Operation         `json:"post,omitempty"`
+	Delete     *Operation         `json:"delete,omitempty"`
+	Options    *Operation         `json:"options,omitempty"`
+	Head       *Operation         `json:"head,omitempty"`
+	Patch      *Operation         `json:"patch,omitempty"`
+	Parameters []Parameter        `json:"parameters,omitempty"`
+	Extensions openapi.Extensions `json:"-"`
+}
+
+// Operation represents a single API operation on a path
+type Operation struct {
+	Tags                []string                       `json:"tags,omitempty"`
+	Summary             string                         `json:"summary,omitempty"`
+	Description         string                         `json:"description,omitempty"`
+	ExternalDocs        *openapi.ExternalDocumentation `json:"externalDocs,omitempty"`
+	OperationID         string                         `json:"operationId,omitempty"`
+	Consumes            []string                       `json:"consumes,omitempty"`
+	Produces            []string                       `json:"produces,omitempty"`
+	Parameters          []Parameter                    `json:"parameters,omitempty"`
+	Responses           map[string]Response            `json:"responses"`
+	ResponsesExtensions openapi.Extensions             `json:"-"` // x- extensions of the responses object
+	Schemes             []string                       `json:"schemes,omitempty"`
+	Deprecated          bool                           `json:"deprecated,omitempty"`
+	Security            []map[string][]string          `json:"security,omitempty"`
+	Extensions          openapi.Extensions             `json:"-"`
+}
+
+// Parameter describes a single operation parameter. Body parameters carry a
+// Schema, all others describe their value with the simple type fields.
+type Parameter struct {
+	Ref              string          `json:"$ref,omitempty"`
+	Name             string          `json:"name,omitempty"`
+	In               string          `json:"in,omitempty"`
+	Description      string          `json:"description,omitempty"`
+	Required         bool            `json:"required,omitempty"`
+	Schema           *openapi.Schema `json:"schema,omitempty"` // Only for in: body
+	AllowEmptyValue  bool            `json:"allowEmptyValue,omitempty"`
+	CollectionFormat string          `json:"collectionFormat,omitempty"`
+	SimpleSchema
+	Extensions openapi.Extensions `json:"-"`
+}
+
+// SimpleSchema is the subset of JSON Schema used by non-body parameters,
+// headers and array items
+type SimpleSchema struct {
+	Type             string          `json:"type,omitempty"`
+	Format           string          `json:"format,omitempty"`
+	Items            *Items          `json:"items,omitempty"`
+	Default          json.RawMessage `json:"default,omitempty"`
+	Maximum          *float64        `json:"maximum,omitempty"`
+	ExclusiveMaximum bool            `json:"exclusiveMaximum,omitempty"`
+	Minimum          *float64        `json:"minimum,omitempty"`
+	ExclusiveMinimum bool            `json:"exclusiveMinimum,omitempty"`
+	MaxLength        *int            `json:"maxLength,omitempty"`
+	MinLength        *int            `json:"minLength,omitempty"`
+	Pattern          string          `json:"pattern,omitempty"`
+	MaxItems         *int            `json:"maxItems,omitempty"`
+	MinItems         *int            `json:"minItems,omitempty"`
+	UniqueItems      bool            `json:"uniqueItems,omitempty"`
+	Enum             []interface{}   `json:"enum,omitempty"`
+	MultipleOf       *float64        `json:"multipleOf,omitempty"`
+}
+
+// Items describes the elements of an array parameter or header
+type Items struct {
+	CollectionFormat string `json:"collectionFormat,omitempty"`
+	SimpleSchema
+	Extensions openapi.Extensions `json:"-"`
+}
+
+// Response represents a response from an API operation
+type Response struct {
+	Ref         string                     `json:"$ref,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Schema      *openapi.Schema            `json:"schema,omitempty"`
+	Headers     map[string]Header          `json:"headers,omitempty"`
+	Examples    map[string]json.RawMessage `json:"examples,omitempty"` // Keyed by mime type
+	Extensions  openapi.Extensions         `json:"-"`
+}
+
+// Header represents a single header in a response
+type Header struct {
+	Description      string `json:"description,omitempty"`
+	CollectionFormat string `json:"collectionFormat,omitempty"`
+	SimpleSchema
+	Extensions openapi.Extensions `json:"-"`
+}
+
+// SecurityScheme defines a security scheme that can be used by the operations
+type SecurityScheme struct {
+	Type             string             `json:"type"`
+	Description      string             `json:"description,omitempty"`
+	Name             string             `json:"name,omitempty"`
+	In               string             `json:"in,omitempty"`
+	Flow             string             `json:"flow,omitempty"`
+	AuthorizationURL string             `json:"authorizationUrl,omitempty"`
+	TokenURL         string             `json:"tokenUrl,omitempty"`
+	Scopes           map[string]string  `json:"scopes,omitempty"`
+	Extensions       openapi.Extensions `json:"-"`
+}